@@ -0,0 +1,293 @@
+// Package blueprint reads worldcraft blueprint files into a typed syntax tree, reporting what it cannot make sense of by
+// file, line and column, and checks a blueprint against a legend of glyphs (see Lint) before anything is built from it
+//
+//     bp, err := blueprint.Parse("blueprints/adventure/blueprint.homestead")
+//     ...
+//     for _, diag := range blueprint.Lint(bp, legend) {
+//         fmt.Println(diag)
+//     }
+//
+// a blueprint is a series of lines, each one of :
+//
+//     ## ...                      a comment, to the end of the line, on a line of its own or after anything else
+//     %%  name  :  value          a directive for the blueprint as a whole (e.g., '%% biome : desert')
+//     %%  include  :  FILE @ X,Y,Z
+//                                 another blueprint file, built within this one, X east, Y up and Z south of its west,
+//                                 bottom, north corner (see Load)
+//     %%  repeat  :  COUNT AXIS [STRIDE] [alternate GLYPH,GLYPH/GLYPH,GLYPH]
+//                                 the rows, or whole layers, up to the matching '%% end : repeat', built COUNT times
+//                                 over along x, y or z, each copy STRIDE blocks on from the last, and optionally
+//                                 swapping glyphs, copy by copy (e.g., '%% repeat : 8 x 14 alternate r,O/O,r')
+//     ==  tagname  :  ELEM:data   a glyph-tag definition : inventory items (e.g., 'SEDw:64'), with '----:--' for an empty
+//                                 slot, or an entity (e.g., 'NTTY:sheep_black'); a glyph-tag's items can span many lines
+//     G G G ...  ::  tag tag:N    a row of glyphs, west to east, optionally followed by the glyph-tags for those glyphs on
+//                                 the row that take one, in order; a ':N' suffix also sets the block's data (e.g., which
+//                                 way a chest faces)
+//     --                          the end of a layer; rows run north to south within a layer, and layers bottom to top
+//
+package blueprint
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  declare our internal datatypes and their interfaces  //////////////////////////////////////////////////////////////////////
+
+// a position is where in a blueprint file something was written; lines and columns count from 1
+//
+type Pos struct {
+	File string
+	Line int
+	Col  int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// the syntax tree of a blueprint :  the glyph-tags are defined for the blueprint as a whole, wherever in the file they are
+// written, so they are kept apart from the layers, in the order they were written
+//
+type Blueprint struct {
+	File       string
+	Directives []*Directive
+	Includes   []*Include
+	Tags       []*TagDef
+	Layers     []*Layer
+}
+
+type Directive struct {
+	Pos   Pos
+	Name  string
+	Value string
+}
+
+// one '%% include' line; Load reads the blueprint it names, which Parse leaves nil
+//
+type Include struct {
+	Pos       Pos
+	File      string
+	X         int
+	Y         int
+	Z         int
+	Blueprint *Blueprint
+}
+
+// one '==' line; a glyph-tag is all of the lines with its name, taken together
+//
+type TagDef struct {
+	Pos   Pos
+	Name  string
+	Elems []*TagElem
+}
+
+type TagElem struct {
+	Pos   Pos
+	Glyph string
+	Data  string
+}
+
+// a layer that has no rows still counts, as a layer of nothing, in the height of everything above it
+//
+type Layer struct {
+	Pos  Pos
+	Rows []*Row
+}
+
+// a nil cell is a gap left between the copies of a repeat block, where nothing is built
+//
+type Row struct {
+	Pos   Pos
+	Cells []*Cell
+	Tags  []*TagRef
+}
+
+type Cell struct {
+	Pos   Pos
+	Glyph string
+}
+
+type TagRef struct {
+	Pos     Pos
+	Name    string
+	Data    int
+	HasData bool
+}
+
+// the directives a blueprint can give
+//
+var directiveNames = map[string]bool{
+	"biome":   true,
+	"include": true,
+	"repeat":  true,
+	"end":     true,
+}
+
+// Extent is the footprint and height of a blueprint : the most glyphs on any row, the most rows in any layer, and the
+// number of layers; or, if further, as far as any blueprint it includes reaches
+//
+func (bp *Blueprint) Extent() (width int, depth int, height int) {
+	for _, layer := range bp.Layers {
+		if len(layer.Rows) > depth {
+			depth = len(layer.Rows)
+		}
+
+		for _, row := range layer.Rows {
+			if len(row.Cells) > width {
+				width = len(row.Cells)
+			}
+		}
+	}
+	height = len(bp.Layers)
+
+	for _, inc := range bp.Includes {
+		if inc.Blueprint == nil {
+			continue
+		}
+
+		incWidth, incDepth, incHeight := inc.Blueprint.Extent()
+		if incWidth > 0 && inc.X + incWidth > width {
+			width = inc.X + incWidth
+		}
+		if incDepth > 0 && inc.Z + incDepth > depth {
+			depth = inc.Z + incDepth
+		}
+		if incHeight > 0 && inc.Y + incHeight > height {
+			height = inc.Y + incHeight
+		}
+	}
+
+	return
+}
+
+// Blueprints lists a blueprint and every blueprint it includes, however deeply, each one once, in the order they are
+// first reached
+//
+func (bp *Blueprint) Blueprints() (bps []*Blueprint) {
+	seen := make(map[*Blueprint]bool, 0)
+
+	var walk func(*Blueprint)
+	walk = func(elem *Blueprint) {
+		if elem == nil || seen[elem] {
+			return
+		}
+		seen[elem] = true
+		bps = append(bps, elem)
+
+		for _, inc := range elem.Includes {
+			walk(inc.Blueprint)
+		}
+	}
+	walk(bp)
+
+	return
+}
+
+// a spot is where in a blueprint, as written, a glyph is :  X east, Y up and Z south of its west, bottom, north corner, and
+// where in which file it was written
+//
+type Spot struct {
+	X   int
+	Y   int
+	Z   int
+	Pos Pos
+}
+
+// Find lists every spot the glyph is at, in the blueprint and in the blueprints it includes, each of those from its own
+// corner within this one
+//
+func (bp *Blueprint) Find(glyph string) (spots []Spot) {
+	for dy, layer := range bp.Layers {
+		for dz, row := range layer.Rows {
+			for dx, cell := range row.Cells {
+				if cell != nil && cell.Glyph == glyph {
+					spots = append(spots, Spot{dx, dy, dz, cell.Pos})
+				}
+			}
+		}
+	}
+
+	for _, inc := range bp.Includes {
+		if inc.Blueprint == nil {
+			continue
+		}
+
+		for _, elem := range inc.Blueprint.Find(glyph) {
+			spots = append(spots, Spot{inc.X + elem.X, inc.Y + elem.Y, inc.Z + elem.Z, elem.Pos})
+		}
+	}
+
+	return
+}
+
+// a diagnostic is a problem found with a blueprint, at the place in the file it was found;  an error is something that
+// cannot be built as written, and a warning something that can, but likely not as meant
+//
+type Diagnostic struct {
+	Pos      Pos
+	Severity string
+	Message  string
+}
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Pos, d.Severity, d.Message)
+}
+
+// Diagnostics is the error Parse returns, when a blueprint has lines it cannot make sense of
+//
+type Diagnostics []Diagnostic
+
+func (ds Diagnostics) Error() string {
+	lines := make([]string, 0, len(ds))
+	for _, elem := range ds {
+		lines = append(lines, elem.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// HasErrors is whether any of the diagnostics is an error, rather than a warning
+//
+func (ds Diagnostics) HasErrors() bool {
+	for _, elem := range ds {
+		if elem.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sort puts the diagnostics in file order
+//
+func (ds Diagnostics) Sort() {
+	sort.SliceStable(ds, func(a, b int) bool {
+		if ds[a].Pos.File != ds[b].Pos.File {
+			return ds[a].Pos.File < ds[b].Pos.File
+		}
+		if ds[a].Pos.Line != ds[b].Pos.Line {
+			return ds[a].Pos.Line < ds[b].Pos.Line
+		}
+		return ds[a].Pos.Col < ds[b].Pos.Col
+	})
+}
+
+func errorAt(pos Pos, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{pos, SeverityError, fmt.Sprintf(format, args...)}
+}
+
+func warningAt(pos Pos, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{pos, SeverityWarning, fmt.Sprintf(format, args...)}
+}
+
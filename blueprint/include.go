@@ -0,0 +1,96 @@
+package blueprint
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// including
+
+// Load reads the blueprint file at filename, as Parse does, along with every blueprint it includes, however deeply;  an
+// included file is named relative to the file including it, and is read once, however many times it is included, so
+// that, e.g., a rank of chess pieces can include the same pawn eight times
+//
+// the glyph-tags of every blueprint read are one set, shared by all of them (see Lint), and a blueprint that includes
+// itself, directly or by way of others, is an error at the include that would close the loop
+//
+func Load(filename string) (bp *Blueprint, err error) {
+	var diags Diagnostics
+
+	bp, err = load(filename, nil, make(map[string]*Blueprint, 0), &diags)
+	if err != nil {
+		return
+	}
+
+	if len(diags) > 0 {
+		diags.Sort()
+		err = diags
+	}
+
+	return
+}
+
+// load reads one blueprint file and, in turn, those it includes;  chain is the files being read on the way to this one,
+// to catch an include that leads back to one of them
+//
+func load(filename string, chain []string, loaded map[string]*Blueprint, diags *Diagnostics) (bp *Blueprint, err error) {
+	key, err := filepath.Abs(filename)
+	if err != nil {
+		return
+	}
+	if bp = loaded[key]; bp != nil {
+		return
+	}
+
+	bp, err = Parse(filename)
+	if err != nil {
+		parseDiags, okay := err.(Diagnostics)
+		if !okay {
+			return nil, err
+		}
+		*diags = append(*diags, parseDiags...)
+		err = nil
+	}
+	loaded[key] = bp
+	chain = append(chain, filename)
+
+	for _, inc := range bp.Includes {
+		path := inc.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(filename), path)
+		}
+
+		if cycle := includeCycle(chain, path); cycle != nil {
+			*diags = append(*diags, errorAt(inc.Pos, "blueprint [%s] includes itself, by way of [%s]", path, strings.Join(cycle, " -> ")))
+			continue
+		}
+
+		inc.Blueprint, err = load(path, chain, loaded, diags)
+		if err != nil {
+			*diags = append(*diags, errorAt(inc.Pos, "unable to read included blueprint [%s] [%s]", path, err))
+			err = nil
+		}
+	}
+
+	return
+}
+
+// includeCycle is, if path is one of the files on the chain, the loop from it back to itself; nil otherwise
+//
+func includeCycle(chain []string, path string) (cycle []string) {
+	key, _ := filepath.Abs(path)
+
+	for indx, elem := range chain {
+		if have, _ := filepath.Abs(elem); have == key {
+			cycle = append(cycle, chain[indx:]...)
+			cycle = append(cycle, path)
+			return
+		}
+	}
+
+	return
+}
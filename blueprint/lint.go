@@ -0,0 +1,210 @@
+package blueprint
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"strconv"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// linting
+
+// a legend tells Lint what each glyph stands for
+//
+type Legend interface {
+	// Kind is what a glyph stands for : 'block', 'item' or 'entity'; or "" for a glyph not in the legend
+	Kind(glyph string) string
+
+	// Slots is, for a block glyph with an inventory (e.g., a chest), how many items a glyph-tag can list for it; 0 for
+	// every other glyph
+	Slots(glyph string) int
+
+	// NeedsTag is whether an entity glyph stands for whatever entity its glyph-tag defines, and so cannot go without one
+	NeedsTag(glyph string) bool
+
+	// Entity is whether an entity of the given name can be built, for a glyph-tag's 'NTTY:name' element
+	Entity(name string) bool
+}
+
+// the placeholder for an empty inventory slot in a glyph-tag
+//
+const emptySlot = "----"
+
+// what a glyph-tag defines, taken over all of its lines
+//
+type tagSummary struct {
+	pos      Pos
+	file     string
+	items    int
+	entities int
+	used     bool
+}
+
+// Lint checks a blueprint against a legend, for everything that would not be built as written :  unknown glyphs, glyph-
+// tags that are undefined, unused, of the wrong kind or too full for their glyph, rows listing more glyph-tags than they
+// have glyphs to take them, and rows of a layer that differ in length;  the diagnostics are in file order
+//
+// a blueprint that includes others (see Load) is checked along with all of them, as one :  a glyph-tag defined in any of
+// them can be used in any of them, but only one of them can define it
+//
+func Lint(bp *Blueprint, legend Legend) (diags Diagnostics) {
+	bps := bp.Blueprints()
+
+	// first, what each glyph-tag is
+	tags := make(map[string]*tagSummary, 0)
+	for _, def := range allTags(bps) {
+		summary := tags[def.Name]
+		if summary == nil {
+			summary = &tagSummary{pos: def.Pos, file: def.Pos.File}
+			tags[def.Name] = summary
+		}
+		if summary.file != def.Pos.File {
+			diags = append(diags, errorAt(def.Pos, "glyph-tag [%s] is already defined, in another blueprint, at %s", def.Name, summary.pos))
+			continue
+		}
+
+		for _, elem := range def.Elems {
+			if elem.Glyph == emptySlot {
+				summary.items++
+				continue
+			}
+
+			switch legend.Kind(elem.Glyph) {
+			case "":
+				diags = append(diags, errorAt(elem.Pos, "unknown glyph [%s]", elem.Glyph))
+			case "item":
+				if qty, err := strconv.Atoi(elem.Data); err != nil || qty < 1 || qty > 127 {
+					diags = append(diags, errorAt(elem.Pos, "item [%s] needs a count from 1 to 127, not [%s]", elem.Glyph, elem.Data))
+				}
+				summary.items++
+			case "entity":
+				if !legend.Entity(elem.Data) {
+					diags = append(diags, errorAt(elem.Pos, "unknown entity [%s]", elem.Data))
+				}
+				summary.entities++
+			default:
+				diags = append(diags, errorAt(elem.Pos, "glyph [%s] is a %s; a glyph-tag lists items, or an entity", elem.Glyph, legend.Kind(elem.Glyph)))
+			}
+		}
+
+		if summary.items > 0 && summary.entities > 0 {
+			diags = append(diags, errorAt(def.Pos, "glyph-tag [%s] lists both items and an entity", def.Name))
+		}
+	}
+
+	// then the layers, row by row
+	for _, elem := range bps {
+		for _, layer := range elem.Layers {
+			for indxRow, row := range layer.Rows {
+				if indxRow > 0 && len(row.Cells) != len(layer.Rows[0].Cells) {
+					diags = append(diags, warningAt(row.Pos, "row has %d glyphs, where the first row of its layer (line %d) has %d", len(row.Cells), layer.Rows[0].Pos.Line, len(layer.Rows[0].Cells)))
+				}
+
+				diags = append(diags, lintRow(row, tags, legend)...)
+			}
+		}
+	}
+
+	for _, def := range allTags(bps) {
+		if summary := tags[def.Name]; !summary.used && summary.pos == def.Pos {
+			diags = append(diags, warningAt(def.Pos, "glyph-tag [%s] is never used", def.Name))
+		}
+	}
+
+	diags.Sort()
+
+	// the copies of a repeat block are checked one by one, but each problem is worth reporting only once;  the problems
+	// at one place come copy by copy, so a repeat of one is not always next to it
+	seen := make(map[Diagnostic]bool, 0)
+	unique := diags[:0]
+	for _, elem := range diags {
+		if !seen[elem] {
+			seen[elem] = true
+			unique = append(unique, elem)
+		}
+	}
+	diags = unique
+
+	return
+}
+
+// the glyph-tags of a row go to the glyphs that take them, in order, as the row is rendered :  an entity glyph that needs
+// a glyph-tag always takes the next one, and an inventory glyph takes the next one if there are any left
+//
+func lintRow(row *Row, tags map[string]*tagSummary, legend Legend) (diags Diagnostics) {
+	takers := 0
+	indxTag := 0
+
+	for _, cell := range row.Cells {
+		if cell == nil {
+			continue
+		}
+
+		kind := legend.Kind(cell.Glyph)
+		switch kind {
+		case "":
+			diags = append(diags, errorAt(cell.Pos, "unknown glyph [%s]", cell.Glyph))
+			continue
+		case "item":
+			diags = append(diags, errorAt(cell.Pos, "glyph [%s] is an item; items go in glyph-tags", cell.Glyph))
+			continue
+		}
+
+		slots := legend.Slots(cell.Glyph)
+		needsTag := kind == "entity" && legend.NeedsTag(cell.Glyph)
+		if slots == 0 && !needsTag {
+			continue
+		}
+		takers++
+
+		if indxTag >= len(row.Tags) {
+			if needsTag {
+				diags = append(diags, errorAt(cell.Pos, "glyph [%s] needs a glyph-tag, and the row has none left for it", cell.Glyph))
+			}
+			continue
+		}
+		ref := row.Tags[indxTag]
+		indxTag++
+
+		summary := tags[ref.Name]
+		if summary == nil {
+			diags = append(diags, errorAt(ref.Pos, "undefined glyph-tag [%s]", ref.Name))
+			continue
+		}
+		summary.used = true
+
+		if needsTag && summary.entities == 0 {
+			diags = append(diags, errorAt(ref.Pos, "glyph-tag [%s] lists items, but glyph [%s] needs an entity", ref.Name, cell.Glyph))
+		}
+		if slots > 0 && summary.entities > 0 {
+			diags = append(diags, errorAt(ref.Pos, "glyph-tag [%s] is an entity, but glyph [%s] needs items", ref.Name, cell.Glyph))
+		}
+		if slots > 0 && summary.items > slots {
+			diags = append(diags, errorAt(ref.Pos, "glyph-tag [%s] fills %d slots, but glyph [%s] has only %d", ref.Name, summary.items, cell.Glyph, slots))
+		}
+	}
+
+	// an inventory glyph left without a glyph-tag is simply built empty, but a glyph-tag left without a glyph is never built
+	for _, ref := range row.Tags[indxTag:] {
+		diags = append(diags, warningAt(ref.Pos, "glyph-tag [%s] is left over; the row has only %d glyphs that take one", ref.Name, takers))
+
+		if summary := tags[ref.Name]; summary != nil {
+			summary.used = true
+		} else {
+			diags = append(diags, errorAt(ref.Pos, "undefined glyph-tag [%s]", ref.Name))
+		}
+	}
+
+	return
+}
+
+// allTags is every glyph-tag definition of a set of blueprints, blueprint by blueprint
+//
+func allTags(bps []*Blueprint) (defs []*TagDef) {
+	for _, elem := range bps {
+		defs = append(defs, elem.Tags...)
+	}
+
+	return
+}
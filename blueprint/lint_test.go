@@ -0,0 +1,143 @@
+package blueprint
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// a legend of a few glyphs :  A a block, C a chest of 2 slots, NTTY an entity that needs a glyph-tag, and SEDw and BRDd
+// items; sheep_black is the only entity there is
+//
+type testLegend struct{}
+
+func (testLegend) Kind(glyph string) string {
+	return map[string]string{"A": "block", "C": "block", "NTTY": "entity", "SEDw": "item", "BRDd": "item"}[glyph]
+}
+
+func (testLegend) Slots(glyph string) int {
+	if glyph == "C" {
+		return 2
+	}
+
+	return 0
+}
+
+func (testLegend) NeedsTag(glyph string) bool {
+	return glyph == "NTTY"
+}
+
+func (testLegend) Entity(name string) bool {
+	return name == "sheep_black"
+}
+
+// lintString parses and lints a blueprint written out in a test, as the file "bp", with the diagnostics as strings
+//
+func lintString(t *testing.T, src string) (diags []string) {
+	bp, parseDiags := parseString(t, src)
+	if parseDiags != nil {
+		t.Fatalf("unexpected parse diagnostics : %v", parseDiags)
+	}
+
+	for _, elem := range Lint(bp, testLegend{}) {
+		diags = append(diags, elem.String())
+	}
+
+	return
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"all as it should be", "==  loot  :  SEDw:64  ----:--\n==  flock  :  NTTY:sheep_black\nA  C  NTTY  C  ::  loot:3  flock  loot",
+			nil},
+
+		{"unknown glyph", "A  Z",
+			[]string{"bp:1:4: error: unknown glyph [Z]"}},
+		{"item on a row", "SEDw",
+			[]string{"bp:1:1: error: glyph [SEDw] is an item; items go in glyph-tags"}},
+
+		{"ragged rows", "A A A\nA A\n--\nA\nA A",
+			[]string{
+				"bp:2:1: warning: row has 2 glyphs, where the first row of its layer (line 1) has 3",
+				"bp:5:1: warning: row has 2 glyphs, where the first row of its layer (line 4) has 1",
+			}},
+
+		{"glyph-tag elements", "==  loot  :  A:1  SEDw:0  SEDw:128  ZZ:1\n==  flock  :  NTTY:unicorn\nC  NTTY  ::  loot  flock",
+			[]string{
+				"bp:1:14: error: glyph [A] is a block; a glyph-tag lists items, or an entity",
+				"bp:1:19: error: item [SEDw] needs a count from 1 to 127, not [0]",
+				"bp:1:27: error: item [SEDw] needs a count from 1 to 127, not [128]",
+				"bp:1:37: error: unknown glyph [ZZ]",
+				"bp:2:15: error: unknown entity [unicorn]",
+			}},
+		{"glyph-tag of items and an entity", "==  mixed  :  SEDw:1  NTTY:sheep_black\nC  ::  mixed",
+			[]string{
+				"bp:1:1: error: glyph-tag [mixed] lists both items and an entity",
+				"bp:2:8: error: glyph-tag [mixed] is an entity, but glyph [C] needs items",
+			}},
+		{"glyph-tags of the wrong kind", "==  loot  :  SEDw:1\n==  flock  :  NTTY:sheep_black\nC  NTTY  ::  flock  loot",
+			[]string{
+				"bp:3:14: error: glyph-tag [flock] is an entity, but glyph [C] needs items",
+				"bp:3:21: error: glyph-tag [loot] lists items, but glyph [NTTY] needs an entity",
+			}},
+
+		// the items of a glyph-tag are counted over all of its lines, empty slots among them
+		{"inventory overflow", "==  loot  :  SEDw:1  ----:--\n==  loot  :  BRDd:3\nC  ::  loot",
+			[]string{"bp:3:8: error: glyph-tag [loot] fills 3 slots, but glyph [C] has only 2"}},
+		{"inventory filled", "==  loot  :  SEDw:1\n==  loot  :  BRDd:3\nC  ::  loot",
+			nil},
+
+		{"glyph-tags missing, undefined and unused", "==  spare  :  SEDw:1\nNTTY  C\n--\nNTTY  ::  ghost\n--\nA  ::  ghost",
+			[]string{
+				"bp:1:1: warning: glyph-tag [spare] is never used",
+				"bp:2:1: error: glyph [NTTY] needs a glyph-tag, and the row has none left for it",
+				"bp:4:11: error: undefined glyph-tag [ghost]",
+				"bp:6:8: warning: glyph-tag [ghost] is left over; the row has only 0 glyphs that take one",
+				"bp:6:8: error: undefined glyph-tag [ghost]",
+			}},
+		{"glyph-tag left over", "==  loot  :  SEDw:1\nC  ::  loot  loot",
+			[]string{"bp:2:14: warning: glyph-tag [loot] is left over; the row has only 1 glyphs that take one"}},
+
+		// every copy of a repeat block is checked, at the place the block was written, but each problem is reported once
+		{"repeated along x", "%%  repeat  :  3  x\nA  Z  ::  loot\n%%  end  :  repeat",
+			[]string{
+				"bp:2:4: error: unknown glyph [Z]",
+				"bp:2:11: warning: glyph-tag [loot] is left over; the row has only 0 glyphs that take one",
+				"bp:2:11: error: undefined glyph-tag [loot]",
+			}},
+		{"repeated along z", "%%  repeat  :  2  z\nA A\nA\n%%  end  :  repeat",
+			[]string{"bp:3:1: warning: row has 1 glyphs, where the first row of its layer (line 2) has 2"}},
+	}
+
+	for _, tt := range tests {
+		diags := lintString(t, tt.src)
+		if !reflect.DeepEqual(diags, tt.want) {
+			t.Errorf("%s : diagnostics are\n    %s\nwant\n    %s", tt.name, strings.Join(diags, "\n    "), strings.Join(tt.want, "\n    "))
+		}
+	}
+}
+
+// a glyph-tag defined in one blueprint can be used in another that it is built along with, but not defined in both
+//
+func TestLintIncludes(t *testing.T) {
+	outer, _ := ParseReader("outer", strings.NewReader("==  loot  :  SEDw:1\n%%  include  :  inner\nA"))
+	inner, _ := ParseReader("inner", strings.NewReader("==  loot  :  BRDd:1\nC  ::  loot"))
+	outer.Includes[0].Blueprint = inner
+
+	var diags []string
+	for _, elem := range Lint(outer, testLegend{}) {
+		diags = append(diags, elem.String())
+	}
+
+	want := []string{"inner:1:1: error: glyph-tag [loot] is already defined, in another blueprint, at outer:1:1"}
+	if !reflect.DeepEqual(diags, want) {
+		t.Errorf("diagnostics are\n    %s\nwant\n    %s", strings.Join(diags, "\n    "), strings.Join(want, "\n    "))
+	}
+}
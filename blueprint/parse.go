@@ -0,0 +1,314 @@
+package blueprint
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// parsing
+
+var regexpDirective = regexp.MustCompile(`^%%\s+([a-z]+)\s*:\s*(\S.*)$`)
+var regexpTagDef = regexp.MustCompile(`^==\s+([a-z]+)\s*:`)
+var regexpTagElem = regexp.MustCompile(`^([-A-Za-z]{1,4}):([-_a-z0-9]+)$`)
+var regexpTagRef = regexp.MustCompile(`^([a-z]+)(?::([0-9]+))?$`)
+var regexpInclude = regexp.MustCompile(`^(\S+)(?:\s+@\s*([0-9]+)\s*,\s*([0-9]+)\s*,\s*([0-9]+))?$`)
+
+// Parse reads the blueprint file at filename; see ParseReader
+//
+func Parse(filename string) (bp *Blueprint, err error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer fh.Close()
+
+	return ParseReader(filename, fh)
+}
+
+// ParseReader reads a blueprint, naming it filename in the positions of what it finds;  lines that cannot be made sense
+// of are returned as Diagnostics, along with a blueprint of everything else, so that every problem can be reported at once
+//
+func ParseReader(filename string, rdr io.Reader) (bp *Blueprint, err error) {
+	var diags Diagnostics
+
+	bp = &Blueprint{File: filename}
+	layer := &Layer{}
+
+	// the repeat blocks we are within, innermost last
+	var repeats []*repeat
+
+	scanner := bufio.NewScanner(rdr)
+	numLine := 0
+	for scanner.Scan() {
+		numLine++
+		linein := scanner.Text()
+
+		// strip off comments, marked by '##'
+		if indx := strings.Index(linein, "##"); indx >= 0 {
+			linein = linein[:indx]
+		}
+
+		// what is left is read from its first non-blank character, keeping track of its column
+		trimmed := strings.TrimLeft(linein, " \t")
+		pos := Pos{filename, numLine, len(linein) - len(trimmed) + 1}
+		trimmed = strings.TrimRight(trimmed, " \t\r")
+
+		// skip any blank lines
+		if trimmed == "" { continue }
+
+		// the end-of-layer marker
+		if strings.HasPrefix(trimmed, "--") {
+			if layer.Pos.Line == 0 {
+				layer.Pos = pos
+			}
+			bp.Layers = append(bp.Layers, layer)
+			layer = &Layer{}
+			continue
+		}
+
+		// %% gives a directive for the blueprint as a whole
+		if strings.HasPrefix(trimmed, "%%") {
+			matches := regexpDirective.FindStringSubmatch(trimmed)
+			if matches == nil {
+				diags = append(diags, errorAt(pos, "malformed directive; expected '%%%%  name  :  value'"))
+				continue
+			}
+			if !directiveNames[matches[1]] {
+				diags = append(diags, errorAt(pos, "unknown blueprint directive [%s]", matches[1]))
+				continue
+			}
+
+			// a repeat starts a block, parsed on its own, until the matching end
+			if matches[1] == "repeat" {
+				r, diag := parseRepeat(pos, strings.TrimSpace(matches[2]))
+				if diag != nil {
+					diags = append(diags, *diag)
+					r = &repeat{pos: pos, count: 1, axis: "z"}
+				}
+
+				r.outerLayers, r.outerLayer = bp.Layers, layer
+				repeats = append(repeats, r)
+				bp.Layers, layer = nil, &Layer{}
+				continue
+			}
+
+			// the end of a repeat block takes the place of the block with its copies
+			if matches[1] == "end" {
+				if strings.TrimSpace(matches[2]) != "repeat" {
+					diags = append(diags, errorAt(pos, "malformed end; expected '%%%%  end  :  repeat'"))
+					continue
+				}
+				if len(repeats) == 0 {
+					diags = append(diags, errorAt(pos, "end of a repeat that was never started"))
+					continue
+				}
+
+				r := repeats[len(repeats) - 1]
+				repeats = repeats[:len(repeats) - 1]
+				if diag := endRepeat(r, bp.Layers, layer); diag != nil {
+					diags = append(diags, *diag)
+				}
+				bp.Layers, layer = r.outerLayers, r.outerLayer
+				continue
+			}
+
+			// an include names a blueprint file, and where within this one to build it; 0,0,0 if not given
+			if matches[1] == "include" {
+				if len(repeats) > 0 {
+					diags = append(diags, errorAt(pos, "an include cannot be repeated; give each copy an include of its own"))
+					continue
+				}
+
+				incMatches := regexpInclude.FindStringSubmatch(strings.TrimSpace(matches[2]))
+				if incMatches == nil {
+					diags = append(diags, errorAt(pos, "malformed include; expected '%%%%  include  :  FILE @ X,Y,Z', with X, Y and Z of 0 or more"))
+					continue
+				}
+
+				inc := &Include{Pos: pos, File: incMatches[1]}
+				if incMatches[2] != "" {
+					inc.X, _ = strconv.Atoi(incMatches[2])
+					inc.Y, _ = strconv.Atoi(incMatches[3])
+					inc.Z, _ = strconv.Atoi(incMatches[4])
+				}
+				bp.Includes = append(bp.Includes, inc)
+				continue
+			}
+
+			bp.Directives = append(bp.Directives, &Directive{pos, matches[1], strings.TrimSpace(matches[2])})
+			continue
+		}
+
+		// == defines a glyph-tag
+		if strings.HasPrefix(trimmed, "==") {
+			loc := regexpTagDef.FindStringSubmatchIndex(trimmed)
+			if loc == nil {
+				diags = append(diags, errorAt(pos, "malformed glyph-tag; expected '==  tagname  :  GLYPH:data ...'"))
+				continue
+			}
+
+			def := &TagDef{Pos: pos, Name: trimmed[loc[2]:loc[3]]}
+			for _, tok := range fields(trimmed[loc[1]:], pos.Col + loc[1]) {
+				matches := regexpTagElem.FindStringSubmatch(tok.text)
+				if matches == nil {
+					diags = append(diags, errorAt(Pos{filename, numLine, tok.col}, "malformed glyph-tag element [%s]; expected GLYPH:data (e.g., 'SEDw:64')", tok.text))
+					continue
+				}
+
+				def.Elems = append(def.Elems, &TagElem{Pos{filename, numLine, tok.col}, matches[1], matches[2]})
+			}
+			if len(def.Elems) == 0 {
+				diags = append(diags, errorAt(pos, "glyph-tag [%s] lists no elements", def.Name))
+				continue
+			}
+
+			bp.Tags = append(bp.Tags, def)
+			continue
+		}
+
+		// anything else is a row of glyphs, with the glyph-tags for them, if any, after '::'
+		row := &Row{Pos: pos}
+		toks := fields(trimmed, pos.Col)
+		for indx, tok := range toks {
+			if tok.text == "::" {
+				if indx == len(toks) - 1 {
+					diags = append(diags, errorAt(Pos{filename, numLine, tok.col}, "no glyph-tags listed after '::'"))
+				}
+
+				for _, tokTag := range toks[indx + 1:] {
+					posTag := Pos{filename, numLine, tokTag.col}
+
+					matches := regexpTagRef.FindStringSubmatch(tokTag.text)
+					if matches == nil {
+						diags = append(diags, errorAt(posTag, "malformed glyph-tag [%s]; expected tagname or tagname:N", tokTag.text))
+						continue
+					}
+
+					ref := &TagRef{Pos: posTag, Name: matches[1]}
+					if matches[2] != "" {
+						ref.Data, _ = strconv.Atoi(matches[2])
+						ref.HasData = true
+					}
+					row.Tags = append(row.Tags, ref)
+				}
+				break
+			}
+
+			row.Cells = append(row.Cells, &Cell{Pos{filename, numLine, tok.col}, tok.text})
+		}
+
+		if len(layer.Rows) == 0 {
+			layer.Pos = pos
+		}
+		layer.Rows = append(layer.Rows, row)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return
+	}
+
+	// a repeat never ended is built once, as written
+	for len(repeats) > 0 {
+		r := repeats[len(repeats) - 1]
+		repeats = repeats[:len(repeats) - 1]
+		diags = append(diags, errorAt(r.pos, "repeat is never ended; expected '%%%%  end  :  repeat'"))
+
+		r.count = 1
+		endRepeat(r, bp.Layers, layer)
+		bp.Layers, layer = r.outerLayers, r.outerLayer
+	}
+
+	// rows after the last end-of-layer marker make a layer of their own
+	if len(layer.Rows) > 0 {
+		bp.Layers = append(bp.Layers, layer)
+	}
+
+	if len(diags) > 0 {
+		err = diags
+	}
+
+	return
+}
+
+// endRepeat puts the copies of a repeat block where the block was written :  a block of rows at the end of the layer it
+// is within, and a block of whole layers after the layers before it;  a block that cannot be repeated is left as it was
+// written, so that what is in it is still checked
+//
+func endRepeat(r *repeat, blockLayers []*Layer, blockLayer *Layer) (diag *Diagnostic) {
+	rows := blockLayer.Rows
+	layers := blockLayers
+
+	switch {
+	case len(blockLayers) == 0:
+		var expanded []*Row
+		if expanded, diag = r.expandRows(rows); diag == nil {
+			rows = expanded
+		}
+
+	case len(blockLayer.Rows) > 0:
+		d := errorAt(r.pos, "a repeat block is either rows within one layer, or whole layers each ending with '--'; not both")
+		diag = &d
+
+	case len(r.outerLayer.Rows) > 0:
+		d := errorAt(r.pos, "a repeat of whole layers must start a layer of its own, after a '--'")
+		diag = &d
+
+	default:
+		var expanded []*Layer
+		if expanded, diag = r.expandLayers(layers); diag == nil {
+			layers = expanded
+		}
+	}
+
+	// whole layers go after the layers before the block, which ends the layer it started in, if it had any rows
+	if len(layers) > 0 {
+		if len(r.outerLayer.Rows) > 0 {
+			r.outerLayers = append(r.outerLayers, r.outerLayer)
+			r.outerLayer = &Layer{}
+		}
+		r.outerLayers = append(r.outerLayers, layers...)
+	}
+
+	if len(r.outerLayer.Rows) == 0 && len(rows) > 0 {
+		r.outerLayer.Pos = rows[0].Pos
+	}
+	r.outerLayer.Rows = append(r.outerLayer.Rows, rows...)
+
+	return
+}
+
+// a token is a whitespace-separated word of a line, and the column it starts at
+//
+type token struct {
+	text string
+	col  int
+}
+
+func fields(s string, col int) (toks []token) {
+	indx := 0
+	for indx < len(s) {
+		for indx < len(s) && (s[indx] == ' ' || s[indx] == '\t') {
+			indx++
+		}
+
+		beg := indx
+		for indx < len(s) && s[indx] != ' ' && s[indx] != '\t' {
+			indx++
+		}
+
+		if indx > beg {
+			toks = append(toks, token{s[beg:indx], col + beg})
+		}
+	}
+
+	return
+}
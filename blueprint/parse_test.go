@@ -0,0 +1,153 @@
+package blueprint
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseString parses a blueprint written out in a test, as the file "bp", with the diagnostics it finds as strings
+//
+func parseString(t *testing.T, src string) (bp *Blueprint, diags []string) {
+	bp, err := ParseReader("bp", strings.NewReader(src))
+	if err == nil {
+		return
+	}
+
+	ds, okay := err.(Diagnostics)
+	if !okay {
+		t.Fatalf("ParseReader : %s", err)
+	}
+	for _, elem := range ds {
+		diags = append(diags, elem.String())
+	}
+
+	return
+}
+
+// a blueprint of every kind of line, read into its syntax tree
+//
+func TestParse(t *testing.T) {
+	src := strings.Join([]string{
+		"##  a comment, on a line of its own",
+		"%%  biome  :  desert  ## and after a directive",
+		"==  loot  :  SEDw:64  ----:--",
+		"==  loot  :  BRDd:3",
+		"==  flock  :  NTTY:sheep_black",
+		"  A  B  C  ::  loot  flock:3",
+		"A",
+		"--",
+		"",
+		"B B",
+		"--",
+	}, "\n")
+
+	bp, diags := parseString(t, src)
+	if diags != nil {
+		t.Fatalf("unexpected diagnostics : %v", diags)
+	}
+
+	wantDirectives := []*Directive{{Pos{"bp", 2, 1}, "biome", "desert"}}
+	if !reflect.DeepEqual(bp.Directives, wantDirectives) {
+		t.Errorf("directives are %+v, want %+v", bp.Directives, wantDirectives)
+	}
+
+	wantTags := []*TagDef{
+		{Pos{"bp", 3, 1}, "loot", []*TagElem{{Pos{"bp", 3, 14}, "SEDw", "64"}, {Pos{"bp", 3, 23}, "----", "--"}}},
+		{Pos{"bp", 4, 1}, "loot", []*TagElem{{Pos{"bp", 4, 14}, "BRDd", "3"}}},
+		{Pos{"bp", 5, 1}, "flock", []*TagElem{{Pos{"bp", 5, 15}, "NTTY", "sheep_black"}}},
+	}
+	if !reflect.DeepEqual(bp.Tags, wantTags) {
+		t.Errorf("glyph-tags are %+v, want %+v", bp.Tags, wantTags)
+	}
+
+	if len(bp.Layers) != 2 {
+		t.Fatalf("%d layers, want 2", len(bp.Layers))
+	}
+	if len(bp.Layers[0].Rows) != 2 || len(bp.Layers[1].Rows) != 1 {
+		t.Fatalf("layers of %d and %d rows, want 2 and 1", len(bp.Layers[0].Rows), len(bp.Layers[1].Rows))
+	}
+
+	row := bp.Layers[0].Rows[0]
+	wantCells := []*Cell{{Pos{"bp", 6, 3}, "A"}, {Pos{"bp", 6, 6}, "B"}, {Pos{"bp", 6, 9}, "C"}}
+	if !reflect.DeepEqual(row.Cells, wantCells) {
+		t.Errorf("cells are %+v, want %+v", row.Cells, wantCells)
+	}
+
+	// a ':N' suffix sets the data, and says that it was set, so that a ':0' can be told from none at all
+	wantRefs := []*TagRef{{Pos{"bp", 6, 16}, "loot", 0, false}, {Pos{"bp", 6, 22}, "flock", 3, true}}
+	if !reflect.DeepEqual(row.Tags, wantRefs) {
+		t.Errorf("glyph-tag references are %+v, want %+v", row.Tags, wantRefs)
+	}
+
+	if bp.Layers[0].Pos != (Pos{"bp", 6, 3}) || bp.Layers[1].Pos != (Pos{"bp", 10, 1}) {
+		t.Errorf("layers are at %s and %s, want bp:6:3 and bp:10:1", bp.Layers[0].Pos, bp.Layers[1].Pos)
+	}
+}
+
+// every line that cannot be made sense of is reported, where it was written, and the rest of the blueprint still read
+//
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"directive without a value", "%%  biome",
+			[]string{"bp:1:1: error: malformed directive; expected '%%  name  :  value'"}},
+		{"directive with a capital name", "%%  Biome  :  desert",
+			[]string{"bp:1:1: error: malformed directive; expected '%%  name  :  value'"}},
+		{"unknown directive", "  %%  colour  :  red",
+			[]string{"bp:1:3: error: unknown blueprint directive [colour]"}},
+		{"end of something other than a repeat", "%%  end  :  layer",
+			[]string{"bp:1:1: error: malformed end; expected '%%  end  :  repeat'"}},
+		{"end of a repeat never started", "A\n%%  end  :  repeat",
+			[]string{"bp:2:1: error: end of a repeat that was never started"}},
+		{"repeat never ended", "%%  repeat  :  2  x\nA",
+			[]string{"bp:1:1: error: repeat is never ended; expected '%%  end  :  repeat'"}},
+		{"include without a file", "%%  include  :  a @ 1,2",
+			[]string{"bp:1:1: error: malformed include; expected '%%  include  :  FILE @ X,Y,Z', with X, Y and Z of 0 or more"}},
+		{"include within a repeat", "%%  repeat  :  2  x\n%%  include  :  a\nA\n%%  end  :  repeat",
+			[]string{"bp:2:1: error: an include cannot be repeated; give each copy an include of its own"}},
+
+		{"glyph-tag without a colon", "==  loot  SEDw:64",
+			[]string{"bp:1:1: error: malformed glyph-tag; expected '==  tagname  :  GLYPH:data ...'"}},
+		{"glyph-tag with a capital name", "==  Loot  :  SEDw:64",
+			[]string{"bp:1:1: error: malformed glyph-tag; expected '==  tagname  :  GLYPH:data ...'"}},
+		{"glyph-tag element without data", "==  loot  :  SEDw:64  SEDw",
+			[]string{"bp:1:23: error: malformed glyph-tag element [SEDw]; expected GLYPH:data (e.g., 'SEDw:64')"}},
+		{"glyph-tag element with too long a glyph", "==  loot  :  SEDwx:64",
+			[]string{
+				"bp:1:14: error: malformed glyph-tag element [SEDwx:64]; expected GLYPH:data (e.g., 'SEDw:64')",
+				"bp:1:1: error: glyph-tag [loot] lists no elements",
+			}},
+		{"glyph-tag without elements", "==  loot  :",
+			[]string{"bp:1:1: error: glyph-tag [loot] lists no elements"}},
+
+		{"no glyph-tags after '::'", "A  C  ::",
+			[]string{"bp:1:7: error: no glyph-tags listed after '::'"}},
+		{"glyph-tag reference with a capital name", "C  ::  Loot",
+			[]string{"bp:1:8: error: malformed glyph-tag [Loot]; expected tagname or tagname:N"}},
+		{"glyph-tag reference with data that is not a number", "C  C  ::  loot:3  loot:x",
+			[]string{"bp:1:19: error: malformed glyph-tag [loot:x]; expected tagname or tagname:N"}},
+		{"glyph-tag reference with an empty suffix", "C  ::  loot:",
+			[]string{"bp:1:8: error: malformed glyph-tag [loot:]; expected tagname or tagname:N"}},
+
+		{"every problem at once", "%%  biome\nA B\n==  loot  :\n--\nC  ::",
+			[]string{
+				"bp:1:1: error: malformed directive; expected '%%  name  :  value'",
+				"bp:3:1: error: glyph-tag [loot] lists no elements",
+				"bp:5:4: error: no glyph-tags listed after '::'",
+			}},
+	}
+
+	for _, tt := range tests {
+		_, diags := parseString(t, tt.src)
+		if !reflect.DeepEqual(diags, tt.want) {
+			t.Errorf("%s : diagnostics are\n    %s\nwant\n    %s", tt.name, strings.Join(diags, "\n    "), strings.Join(tt.want, "\n    "))
+		}
+	}
+}
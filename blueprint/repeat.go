@@ -0,0 +1,298 @@
+package blueprint
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// repeating
+//
+// a block of lines between '%% repeat : ...' and '%% end : repeat' is written once, and built many times over :
+//
+//     %%  repeat  :  COUNT  AXIS  [STRIDE]  [alternate GLYPH,GLYPH/GLYPH,GLYPH ...]
+//
+// COUNT copies of the block are laid out along AXIS (x east, y up, or z south), each STRIDE blocks on from the last;  the
+// stride is the block's own length along the axis unless given, and can be longer, leaving gaps in which nothing is built,
+// but not shorter
+//
+// a block is either rows within one layer, repeated along x or z, or whole layers, each ending with '--' and the first
+// starting a layer of its own, repeated along x, y or z;  blocks can be nested, so a row can be repeated along x, and the
+// rows that makes repeated along z
+//
+// 'alternate' gives sets of glyphs for the copies to take turns with :  the first set is the glyphs as written, and each
+// copy has those glyphs swapped for the ones in the same place in its set, the sets being taken in turn;  so, for the
+// squares of a chessboard, 'alternate r,O/O,r' has every other copy swap r for O and O for r
+
+// the copies of a block reach at most this far along x or z, and no further up than a chunk is tall;  every copy is laid
+// out in memory, so a count or stride mistyped as much larger would otherwise run the parser out of time or memory
+//
+var maxRepeatReach = map[string]int{"x": 4096, "y": 256, "z": 4096}
+
+var regexpRepeat = regexp.MustCompile(`^([0-9]+)\s+([xyz])(?:\s+([0-9]+))?(?:\s+alternate\s+(\S+))?$`)
+
+// a repeat is a '%% repeat' line, along with what the parser was in the middle of when it reached it, to go back to at
+// the matching '%% end'
+//
+type repeat struct {
+	pos    Pos
+	count  int
+	axis   string
+	stride int
+	sets   [][]string
+
+	outerLayers []*Layer
+	outerLayer  *Layer
+}
+
+// parseRepeat reads the value of a '%% repeat' line
+//
+func parseRepeat(pos Pos, value string) (r *repeat, diag *Diagnostic) {
+	matches := regexpRepeat.FindStringSubmatch(value)
+	if matches == nil {
+		d := errorAt(pos, "malformed repeat; expected '%%%%  repeat  :  COUNT  x|y|z  [STRIDE]  [alternate GLYPH,GLYPH/GLYPH,GLYPH ...]'")
+		return nil, &d
+	}
+
+	r = &repeat{pos: pos, axis: matches[2]}
+	reach := maxRepeatReach[r.axis]
+
+	var err error
+	r.count, err = strconv.Atoi(matches[1])
+	if err != nil || r.count > reach {
+		d := errorAt(pos, "a repeat count of %s is more than the %d copies that fit along %s", matches[1], reach, r.axis)
+		return nil, &d
+	}
+	if matches[3] != "" {
+		r.stride, err = strconv.Atoi(matches[3])
+		if err != nil || r.stride > reach {
+			d := errorAt(pos, "a stride of %s is more than the %d blocks that copies can reach along %s", matches[3], reach, r.axis)
+			return nil, &d
+		}
+	}
+	if r.count < 1 {
+		d := errorAt(pos, "a repeat needs a count of at least 1")
+		return nil, &d
+	}
+
+	if matches[4] != "" {
+		for _, elem := range strings.Split(matches[4], "/") {
+			r.sets = append(r.sets, strings.Split(elem, ","))
+		}
+
+		if len(r.sets) < 2 {
+			d := errorAt(pos, "alternate needs at least two sets of glyphs, separated by '/'")
+			return nil, &d
+		}
+		for _, elem := range r.sets {
+			if len(elem) != len(r.sets[0]) {
+				d := errorAt(pos, "alternate needs sets of glyphs all the same length")
+				return nil, &d
+			}
+		}
+	}
+
+	return
+}
+
+// glyphs is, for the copy numbered indx, what each glyph of the block becomes in it
+//
+func (r *repeat) glyphs(indx int) (subst map[string]string) {
+	if len(r.sets) == 0 || indx % len(r.sets) == 0 {
+		return nil
+	}
+
+	subst = make(map[string]string, 0)
+	for indxGlyph, elem := range r.sets[0] {
+		subst[elem] = r.sets[indx % len(r.sets)][indxGlyph]
+	}
+
+	return
+}
+
+// stretch checks the stride against the block's length along the axis, and settles on the stride to use
+//
+func (r *repeat) stretch(length int) (stride int, diag *Diagnostic) {
+	stride = r.stride
+	if stride == 0 {
+		stride = length
+	}
+	if stride < length {
+		d := errorAt(r.pos, "a stride of %d along %s is less than the block's %d; the copies would overlap", stride, r.axis, length)
+		return 0, &d
+	}
+	if reach := maxRepeatReach[r.axis]; ((r.count - 1) * stride) + length > reach {
+		d := errorAt(r.pos, "%d copies, %d apart along %s, reach further than the %d blocks that copies can reach", r.count, stride, r.axis, reach)
+		return 0, &d
+	}
+
+	return
+}
+
+// expandRows lays out the copies of a block of rows, returning the rows that take the place of the block
+//
+func (r *repeat) expandRows(rows []*Row) (out []*Row, diag *Diagnostic) {
+	width := rowsWidth(rows)
+
+	switch r.axis {
+	case "x":
+		stride, diag := r.stretch(width)
+		if diag != nil {
+			return nil, diag
+		}
+		for _, row := range rows {
+			out = append(out, r.alongX(row, stride))
+		}
+
+	case "z":
+		stride, diag := r.stretch(len(rows))
+		if diag != nil {
+			return nil, diag
+		}
+		out = r.alongZ(rows, width, stride)
+
+	default:
+		d := errorAt(r.pos, "rows repeat along x or z; to repeat along y, repeat whole layers")
+		return nil, &d
+	}
+
+	return
+}
+
+// expandLayers lays out the copies of a block of whole layers, returning the layers that take the place of the block
+//
+func (r *repeat) expandLayers(layers []*Layer) (out []*Layer, diag *Diagnostic) {
+	width := 0
+	depth := 0
+	for _, layer := range layers {
+		if w := rowsWidth(layer.Rows); w > width {
+			width = w
+		}
+		if len(layer.Rows) > depth {
+			depth = len(layer.Rows)
+		}
+	}
+
+	switch r.axis {
+	case "x":
+		stride, diag := r.stretch(width)
+		if diag != nil {
+			return nil, diag
+		}
+		for _, layer := range layers {
+			widened := &Layer{Pos: layer.Pos}
+			for _, row := range layer.Rows {
+				widened.Rows = append(widened.Rows, r.alongX(row, stride))
+			}
+			out = append(out, widened)
+		}
+
+	case "y":
+		stride, diag := r.stretch(len(layers))
+		if diag != nil {
+			return nil, diag
+		}
+		for indx := 0; indx < r.count; indx++ {
+			subst := r.glyphs(indx)
+			for _, layer := range layers {
+				out = append(out, &Layer{layer.Pos, copyRows(layer.Rows, subst)})
+			}
+
+			// a layer of nothing, for each of the layers between one copy and the next
+			for gap := len(layers); indx < r.count - 1 && gap < stride; gap++ {
+				out = append(out, &Layer{Pos: r.pos})
+			}
+		}
+
+	case "z":
+		stride, diag := r.stretch(depth)
+		if diag != nil {
+			return nil, diag
+		}
+		for _, layer := range layers {
+			out = append(out, &Layer{layer.Pos, r.alongZ(layer.Rows, width, stride)})
+		}
+	}
+
+	return
+}
+
+// alongX is one row, with its copies one after another along it, and the glyph-tags of each copy after those of the last
+//
+func (r *repeat) alongX(row *Row, stride int) (out *Row) {
+	out = &Row{Pos: row.Pos}
+
+	for indx := 0; indx < r.count; indx++ {
+		cpy := copyRow(row, r.glyphs(indx))
+		out.Cells = append(out.Cells, cpy.Cells...)
+		out.Tags = append(out.Tags, cpy.Tags...)
+
+		// a gap, of nothing, up to where the next copy starts
+		for indx < r.count - 1 && len(out.Cells) < (indx + 1) * stride {
+			out.Cells = append(out.Cells, nil)
+		}
+	}
+
+	return
+}
+
+// alongZ is a run of rows, with its copies one after another, southward
+//
+func (r *repeat) alongZ(rows []*Row, width int, stride int) (out []*Row) {
+	for indx := 0; indx < r.count; indx++ {
+		out = append(out, copyRows(rows, r.glyphs(indx))...)
+
+		// rows of nothing, up to where the next copy starts
+		for gap := len(rows); indx < r.count - 1 && gap < stride; gap++ {
+			out = append(out, &Row{Pos: r.pos, Cells: make([]*Cell, width)})
+		}
+	}
+
+	return
+}
+
+// copyRows copies rows, swapping glyphs as subst says; the copies keep the positions of what was written, so that any
+// problem with them is reported where it can be fixed
+//
+func copyRows(rows []*Row, subst map[string]string) (out []*Row) {
+	for _, row := range rows {
+		out = append(out, copyRow(row, subst))
+	}
+
+	return
+}
+
+func copyRow(row *Row, subst map[string]string) (out *Row) {
+	out = &Row{Pos: row.Pos, Tags: append([]*TagRef{}, row.Tags...)}
+
+	for _, cell := range row.Cells {
+		if cell == nil {
+			out.Cells = append(out.Cells, nil)
+			continue
+		}
+
+		glyph := cell.Glyph
+		if swapped, okay := subst[glyph]; okay {
+			glyph = swapped
+		}
+		out.Cells = append(out.Cells, &Cell{cell.Pos, glyph})
+	}
+
+	return
+}
+
+// rowsWidth is the most glyphs on any of the rows
+//
+func rowsWidth(rows []*Row) (width int) {
+	for _, row := range rows {
+		if len(row.Cells) > width {
+			width = len(row.Cells)
+		}
+	}
+
+	return
+}
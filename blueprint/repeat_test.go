@@ -0,0 +1,147 @@
+package blueprint
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// layersString writes out the layers of a blueprint, a layer to a string, a row to a line, with '_' for each gap left
+// between copies
+//
+func layersString(bp *Blueprint) (out []string) {
+	for _, layer := range bp.Layers {
+		var lines []string
+		for _, row := range layer.Rows {
+			var glyphs []string
+			for _, cell := range row.Cells {
+				if cell == nil {
+					glyphs = append(glyphs, "_")
+				} else {
+					glyphs = append(glyphs, cell.Glyph)
+				}
+			}
+			lines = append(lines, strings.Join(glyphs, " "))
+		}
+		out = append(out, strings.Join(lines, "\n"))
+	}
+
+	return
+}
+
+func TestRepeat(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"row along x", "%%  repeat  :  3  x\nA B\n%%  end  :  repeat",
+			[]string{"A B A B A B"}},
+		{"row along x, spaced", "%%  repeat  :  3  x  4\nA B\n%%  end  :  repeat",
+			[]string{"A B _ _ A B _ _ A B"}},
+		{"row along x, at its own width", "%%  repeat  :  2  x  2\nA B\n%%  end  :  repeat",
+			[]string{"A B A B"}},
+
+		// each copy starts a stride on from the last, on every row, however long the row; the last copy is not padded out
+		{"ragged rows along x", "%%  repeat  :  3  x\nA B C\nA\n%%  end  :  repeat",
+			[]string{"A B C A B C A B C\nA _ _ A _ _ A"}},
+		{"ragged rows along x, spaced", "%%  repeat  :  2  x  5\nA B C\nA\n%%  end  :  repeat",
+			[]string{"A B C _ _ A B C\nA _ _ _ _ A"}},
+
+		{"rows along z", "%%  repeat  :  2  z\nA B\nB A\n%%  end  :  repeat",
+			[]string{"A B\nB A\nA B\nB A"}},
+		{"rows along z, spaced", "%%  repeat  :  2  z  3\nA B C\nA\n%%  end  :  repeat",
+			[]string{"A B C\nA\n_ _ _\nA B C\nA"}},
+
+		{"within the rows of a layer", "C\n%%  repeat  :  2  x\nA B\n%%  end  :  repeat\nC",
+			[]string{"C\nA B A B\nC"}},
+		{"nested", "%%  repeat  :  2  z  2\n%%  repeat  :  3  x  2\nA\n%%  end  :  repeat\n%%  end  :  repeat",
+			[]string{"A _ A _ A\n_ _ _ _ _\nA _ A _ A"}},
+		{"alternate", "%%  repeat  :  4  x  alternate  r,O/O,r\nr O\n%%  end  :  repeat",
+			[]string{"r O O r r O O r"}},
+
+		{"layers along x", "%%  repeat  :  2  x  3\nA B\n--\nA\n--\n%%  end  :  repeat",
+			[]string{"A B _ A B", "A _ _ A"}},
+		{"layers along y, spaced", "%%  repeat  :  2  y  3\nA\n--\n%%  end  :  repeat",
+			[]string{"A", "", "", "A"}},
+		{"layers along z", "%%  repeat  :  2  z\nA B\n--\nA\nA\n--\n%%  end  :  repeat",
+			[]string{"A B\n_ _\nA B", "A\nA\nA\nA"}},
+	}
+
+	for _, tt := range tests {
+		bp, diags := parseString(t, tt.src)
+		if diags != nil {
+			t.Errorf("%s : unexpected diagnostics : %v", tt.name, diags)
+			continue
+		}
+
+		if got := layersString(bp); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s : layers are\n%s\nwant\n%s", tt.name, strings.Join(got, "\n--\n"), strings.Join(tt.want, "\n--\n"))
+		}
+	}
+}
+
+// a repeat that cannot be laid out is reported, and the block built once as written, so that what is in it is still checked
+//
+func TestRepeatErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"malformed", "%%  repeat  :  2  w", "bp:1:1: error: malformed repeat; expected '%%  repeat  :  COUNT  x|y|z  [STRIDE]  [alternate GLYPH,GLYPH/GLYPH,GLYPH ...]'"},
+		{"count of 0", "%%  repeat  :  0  x", "bp:1:1: error: a repeat needs a count of at least 1"},
+
+		{"count out of range along x", "%%  repeat  :  4097  x", "bp:1:1: error: a repeat count of 4097 is more than the 4096 copies that fit along x"},
+		{"count out of range along y", "%%  repeat  :  257  y", "bp:1:1: error: a repeat count of 257 is more than the 256 copies that fit along y"},
+		{"count too large to read", "%%  repeat  :  99999999999999999999  z", "bp:1:1: error: a repeat count of 99999999999999999999 is more than the 4096 copies that fit along z"},
+		{"stride out of range", "%%  repeat  :  2  x  4097", "bp:1:1: error: a stride of 4097 is more than the 4096 blocks that copies can reach along x"},
+		{"stride too large to read", "%%  repeat  :  2  y  99999999999999999999", "bp:1:1: error: a stride of 99999999999999999999 is more than the 256 blocks that copies can reach along y"},
+
+		{"copies reach too far along x", "%%  repeat  :  300  x  14", "bp:1:1: error: 300 copies, 14 apart along x, reach further than the 4096 blocks that copies can reach"},
+		{"copies reach too far along y", "%%  repeat  :  129  y  2", "bp:1:1: error: 129 copies, 2 apart along y, reach further than the 256 blocks that copies can reach"},
+		{"stride shorter than the block", "%%  repeat  :  2  x  1", "bp:1:1: error: a stride of 1 along x is less than the block's 2; the copies would overlap"},
+
+		{"alternate of one set", "%%  repeat  :  2  x  alternate  r,O", "bp:1:1: error: alternate needs at least two sets of glyphs, separated by '/'"},
+		{"alternate of sets of different lengths", "%%  repeat  :  2  x  alternate  r,O/O", "bp:1:1: error: alternate needs sets of glyphs all the same length"},
+	}
+
+	for _, tt := range tests {
+		// whole layers for along y, and rows within a layer otherwise
+		block := "\nA B\n"
+		if strings.Contains(tt.src, " y") {
+			block = "\nA B\n--\n"
+		}
+
+		bp, diags := parseString(t, tt.src + block + "%%  end  :  repeat")
+		if !reflect.DeepEqual(diags, []string{tt.want}) {
+			t.Errorf("%s : diagnostics are\n    %s\nwant\n    %s", tt.name, strings.Join(diags, "\n    "), tt.want)
+			continue
+		}
+
+		if got := layersString(bp); !reflect.DeepEqual(got, []string{"A B"}) {
+			t.Errorf("%s : layers are %q, want the block once, as written", tt.name, got)
+		}
+	}
+
+	// a block of rows and whole layers at once, or whole layers within a layer, or rows along y, cannot be laid out either
+	tests = []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"rows along y", "%%  repeat  :  2  y\nA\n%%  end  :  repeat", "bp:1:1: error: rows repeat along x or z; to repeat along y, repeat whole layers"},
+		{"rows and layers", "%%  repeat  :  2  x\nA\n--\nA\n%%  end  :  repeat", "bp:1:1: error: a repeat block is either rows within one layer, or whole layers each ending with '--'; not both"},
+		{"layers within a layer", "A\n%%  repeat  :  2  x\nA\n--\n%%  end  :  repeat", "bp:2:1: error: a repeat of whole layers must start a layer of its own, after a '--'"},
+	}
+
+	for _, tt := range tests {
+		_, diags := parseString(t, tt.src)
+		if !reflect.DeepEqual(diags, []string{tt.want}) {
+			t.Errorf("%s : diagnostics are\n    %s\nwant\n    %s", tt.name, strings.Join(diags, "\n    "), tt.want)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/landru27/nbt"
+	"github.com/landru27/worldcraft/world"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// capture : the reverse of rendering a blueprint
+//
+// we walk a box of the world one layer at a time, from the bottom up, and one row at a time, from north to south, looking
+// up the glyph for each block; blocks with inventories get a glyph-tag listing their contents, and entities get a glyph-tag
+// naming the entity atom that best describes them;  the result is a blueprint in the same format that 'render' reads
+//
+// not everything in a world has a glyph; blocks without one are captured as the 'X' null glyph, and items without one are
+// captured as empty inventory slots;  we count these, and report them, so that the legend can be extended as needed
+//
+func captureBlueprint(box world.MCBox, filename string) (err error) {
+	if _, err = os.Stat(filename); err == nil {
+		return fmt.Errorf("file already exists; not overwriting it")
+	}
+
+	var qtyBlocks, qtyBlocksUnmatched int
+	var qtyItemsUnmatched int
+	var qtyEntities, qtyEntitiesSkipped int
+
+	// glyph-tag definitions are collected as we go, but written out ahead of the layers that refer to them
+	var tagLines []string
+	var layerLines []string
+	tagsUsed := make(map[string]bool, 0)
+	entityTags := make(map[string]string, 0)
+
+	// items in a world of 1.13 or later have the ids they were flattened into, and are matched by those instead
+	var flatItemIndx map[string]int
+	if gameworld.Level != nil && gameworld.Level.Format.Codec == "palette" {
+		flatItemIndx = flattenedItemIndx(gameworld.Level.DataVersion)
+	}
+
+	// entities are placed on the blueprint at the block they are standing in; index them by that block
+	//
+	// -skipentities leaves them off the blueprint, as it leaves them out of a render
+	entityCells := make(map[[3]int][]*nbt.NBT, 0)
+	entities := make([]*nbt.NBT, 0)
+	if !gameworld.FlagSkipEntities {
+		entities, err = gameworld.GetEntitiesIn(box)
+		if err != nil {
+			return
+		}
+	}
+	for _, elem := range entities {
+		pos := world.NBTChild(elem, "Pos").Data.([]nbt.NBT)
+		px := int(math.Floor(pos[0].Data.(float64)))
+		py := int(math.Floor(pos[1].Data.(float64)))
+		pz := int(math.Floor(pos[2].Data.(float64)))
+
+		entityCells[[3]int{px, py, pz}] = append(entityCells[[3]int{px, py, pz}], elem)
+	}
+
+	for by := box.MinY; by <= box.MaxY; by++ {
+		for bz := box.MinZ; bz <= box.MaxZ; bz++ {
+			var rowglyphs []string
+			var rowglyphtags []string
+
+			for bx := box.MinX; bx <= box.MaxX; bx++ {
+				id, data, err := gameworld.GetBlock(bx, by, bz)
+				if err == world.ErrNoLegacyBlock {
+					// blocks added to Minecraft since 1.12 have no id and data, and so no glyph
+					qtyBlocks++
+					qtyBlocksUnmatched++
+					qtyEntitiesSkipped += len(entityCells[[3]int{bx, by, bz}])
+					rowglyphs = append(rowglyphs, glyphs[glyphIndx["X"]].Glyph)
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				qtyBlocks++
+
+				// an entity standing in an otherwise empty block takes that block's place on the blueprint; rendering
+				// an entity glyph also places air, so anything else in that block would be lost
+				if cell, okay := entityCells[[3]int{bx, by, bz}]; okay && id == 0 {
+					qtyEntitiesSkipped += len(cell) - 1
+
+					atom := matchEntityAtom(cell[0])
+					if atom == "" {
+						qtyEntitiesSkipped++
+						rowglyphs = append(rowglyphs, ".")
+						continue
+					}
+					qtyEntities++
+
+					// the simple entity glyphs need no glyph-tag; otherwise, use (or define) a glyph-tag for this atom
+					if indx, okay := glyphIndx[atom]; okay && glyphs[indx].Type == "entity" && len(glyphs[indx].Glyph) == 1 && glyphs[indx].Glyph != "E" && glyphs[indx].Glyph != "I" {
+						rowglyphs = append(rowglyphs, glyphs[indx].Glyph)
+						continue
+					}
+
+					tagname, okay := entityTags[atom]
+					if !okay {
+						tagname = lettersOnly(atom)
+						if tagsUsed[tagname] {
+							tagname = uniqueTagName(tagname, tagsUsed)
+						}
+						tagsUsed[tagname] = true
+						entityTags[atom] = tagname
+						tagLines = append(tagLines, fmt.Sprintf("     ==  %-16s:  NTTY:%s", tagname, atom))
+					}
+
+					rowglyphs = append(rowglyphs, "E")
+					rowglyphtags = append(rowglyphtags, tagname)
+					continue
+				}
+
+				if _, okay := entityCells[[3]int{bx, by, bz}]; okay {
+					qtyEntitiesSkipped += len(entityCells[[3]int{bx, by, bz}])
+				}
+
+				// blocks with an inventory are matched by id alone; their data (e.g., the direction a chest faces)
+				// goes onto the glyph-tag as a number suffix, when it differs from the glyph's own data
+				if indx, okay := inventoryGlyph(id); okay {
+					tagname := uniqueTagName(lettersOnly(glyphs[indx].Name), tagsUsed)
+
+					var items *nbt.NBT
+					nbtentity, err := gameworld.GetBlockEntity(bx, by, bz)
+					if err != nil {
+						return err
+					}
+					if nbtentity != nil {
+						items = world.NBTChild(nbtentity, "Items")
+					}
+
+					elems, unmatched := inventoryElements(items, flatItemIndx)
+					qtyItemsUnmatched += unmatched
+					for beg := 0; beg < len(elems); beg += 9 {
+						end := beg + 9
+						if end > len(elems) { end = len(elems) }
+						tagLines = append(tagLines, fmt.Sprintf("     ==  %-16s:  %s", tagname, strings.TrimRight(strings.Join(elems[beg:end], "  "), " ")))
+					}
+
+					if data != glyphs[indx].Data {
+						tagname = fmt.Sprintf("%s:%d", tagname, data)
+					}
+
+					rowglyphs = append(rowglyphs, glyphs[indx].Glyph)
+					rowglyphtags = append(rowglyphtags, tagname)
+					continue
+				}
+
+				indx, okay := glyphBlockIndx[GlyphKey{id, data}]
+				if !okay {
+					qtyBlocksUnmatched++
+					indx = glyphIndx["X"]
+				}
+
+				rowglyphs = append(rowglyphs, glyphs[indx].Glyph)
+			}
+
+			linein := "     " + strings.Join(rowglyphs, " ")
+			if len(rowglyphtags) > 0 {
+				linein += "  ::  " + strings.Join(rowglyphtags, "  ")
+			}
+			layerLines = append(layerLines, linein)
+		}
+
+		layerLines = append(layerLines, "     --")
+	}
+
+	// write out the blueprint : a header noting where it came from, the glyph-tags, and then the layers
+	fh, err := os.Create(filename)
+	if err != nil {
+		return
+	}
+	defer fh.Close()
+
+	wb := bufio.NewWriter(fh)
+	fmt.Fprintf(wb, "##  captured from %s\n", gameworld.PathWorld)
+	fmt.Fprintf(wb, "##  from %d, %d, %d  to %d, %d, %d  at %s\n", box.MinX, box.MinY, box.MinZ, box.MaxX, box.MaxY, box.MaxZ, timeExec.Format(time.RFC3339))
+	fmt.Fprintf(wb, "\n")
+	if len(tagLines) > 0 {
+		fmt.Fprintf(wb, "##  the glyph-tags\n\n")
+		for _, elem := range tagLines {
+			fmt.Fprintf(wb, "%s\n", elem)
+		}
+		fmt.Fprintf(wb, "\n")
+	}
+	fmt.Fprintf(wb, "##  the layers, from the bottom up\n\n")
+	for _, elem := range layerLines {
+		fmt.Fprintf(wb, "%s\n", elem)
+	}
+
+	err = wb.Flush()
+	if err != nil {
+		return
+	}
+
+	// output stats on what was done
+	fmt.Printf("blocks captured            : %d\n", qtyBlocks)
+	fmt.Printf("blocks without a glyph     : %d\n", qtyBlocksUnmatched)
+	fmt.Printf("items without a glyph      : %d\n", qtyItemsUnmatched)
+	fmt.Printf("entities captured          : %d\n", qtyEntities)
+	fmt.Printf("entities skipped           : %d\n", qtyEntitiesSkipped)
+	fmt.Printf("\n")
+
+	return
+}
+
+// an inventory glyph is a block glyph whose pre-defined NBT has an inventory list; these are the glyphs that take a
+// glyph-tag listing their contents (see renderBlueprint)
+//
+func inventoryGlyph(id uint16) (indx int, okay bool) {
+	for indx = range glyphs {
+		if glyphs[indx].Type != "block" || glyphs[indx].ID != id || glyphs[indx].Base == (nbt.NBT{}) {
+			continue
+		}
+
+		if len(glyphs[indx].Base.Data.([]nbt.NBT)) > 4 {
+			if glyphs[indx].Base.Data.([]nbt.NBT)[4].Name == "Items" {
+				okay = true
+				return
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// this turns an inventory list into glyph-tag elements, one per slot, using the '----:--' placeholder for empty slots
+// and for items that have no glyph;  items are looked up by id and Damage, or, given flatItemIndx, by the id and tag.Damage
+// they have since 1.13
+//
+func inventoryElements(items *nbt.NBT, flatItemIndx map[string]int) (elems []string, unmatched int) {
+	elems = make([]string, 0)
+	unmatched = 0
+
+	slots := make(map[int]string, 0)
+	maxslot := -1
+
+	if items != nil {
+		for indx := range items.Data.([]nbt.NBT) {
+			item := &items.Data.([]nbt.NBT)[indx]
+
+			nbtA := world.NBTChild(item, "id")
+			nbtB := world.NBTChild(item, "Slot")
+			nbtC := world.NBTChild(item, "Count")
+			nbtD := world.NBTChild(item, "Damage")
+			if nbtA == nil || nbtB == nil || nbtC == nil {
+				unmatched++
+				continue
+			}
+
+			damage := int16(0)
+			if nbtD != nil { damage = nbtD.Data.(int16) }
+
+			itemIndx := glyphItemIndx
+			if flatItemIndx != nil {
+				itemIndx = flatItemIndx
+				damage = 0
+				if nbtWorn := world.NBTChild(world.NBTChild(item, "tag"), "Damage"); nbtWorn != nil {
+					valu, _ := nbtWorn.Data.(int32)
+					damage = int16(valu)
+				}
+			}
+
+			glyphindx, okay := itemIndx[fmt.Sprintf("%s:%d", nbtA.Data.(string), damage)]
+			if !okay {
+				unmatched++
+				continue
+			}
+
+			slot := int(nbtB.Data.(byte))
+			slots[slot] = fmt.Sprintf("%s:%d", glyphs[glyphindx].Glyph, nbtC.Data.(byte))
+			if slot > maxslot { maxslot = slot }
+		}
+	}
+
+	for slot := 0; slot <= maxslot; slot++ {
+		if elem, okay := slots[slot]; okay {
+			elems = append(elems, fmt.Sprintf("%-7s", elem))
+		} else {
+			elems = append(elems, "----:--")
+		}
+	}
+
+	// a glyph-tag needs at least one element
+	if len(elems) == 0 {
+		elems = append(elems, "----:--")
+	}
+
+	return
+}
+
+// since 1.13, each kind of item has an id of its own, and Damage is only how worn an item is, kept in its tag (see
+// world.LegacyItem);  this indexes the item glyphs by the id and Damage that their items are flattened into, for the given
+// DataVersion, in the same way that glyphItemIndx indexes them by id and Damage as they were
+//
+func flattenedItemIndx(dataVersion int) (rslt map[string]int) {
+	rslt = make(map[string]int, 0)
+
+	for indx, elem := range glyphs {
+		if elem.Type != "item" {
+			continue
+		}
+
+		key := itemKey(elem)
+		split := strings.LastIndex(key, ":")
+		damage, err := strconv.Atoi(key[split + 1:])
+		if err != nil {
+			continue
+		}
+
+		id, worn, err := world.LegacyItem(key[:split], int16(damage), dataVersion)
+		if err != nil {
+			continue
+		}
+		if !worn {
+			damage = 0
+		}
+
+		key = fmt.Sprintf("%s:%d", id, damage)
+		if _, okay := rslt[key]; !okay {
+			rslt[key] = indx
+		}
+	}
+
+	return
+}
+
+// entities are built from a hierarchy of atoms; to capture an entity, we look for the atom whose Minecraft name matches
+// the entity's id, and whose distinguishing attributes (color, name, etc.) all match the entity, preferring the atom that
+// matches the most such attributes -- i.e., the most specific one
+//
+func matchEntityAtom(entity *nbt.NBT) (rslt string) {
+	rslt = ""
+
+	nbtid := world.NBTChild(entity, "id")
+	if nbtid == nil {
+		return
+	}
+
+	best := -1
+	for _, atom := range entityAtoms {
+		var mcname string
+		score := 0
+		mismatch := false
+
+		for next := atom.Name; next != ""; next = entityAtoms[entityAtomIndx[next]].Base {
+			for _, info := range entityAtoms[entityAtomIndx[next]].Info {
+				var nbtattr *nbt.NBT
+				var match bool
+
+				switch info.Attr {
+				case "MCName":
+					if mcname == "" { mcname = info.Valu.(string) }
+					continue
+				case "SheepColor":
+					nbtattr = world.NBTChild(entity, "Color")
+					match = nbtattr != nil && nbtattr.Data.(byte) == byte(info.Valu.(float64))
+				case "CatType":
+					nbtattr = world.NBTChild(entity, "CatType")
+					match = nbtattr != nil && nbtattr.Data.(int32) == int32(info.Valu.(float64))
+				case "CollarColor":
+					nbtattr = world.NBTChild(entity, "CollarColor")
+					match = nbtattr != nil && nbtattr.Data.(byte) == byte(info.Valu.(float64))
+				case "CustomName":
+					nbtattr = world.NBTChild(entity, "CustomName")
+					match = nbtattr != nil && nbtattr.Data.(string) == info.Valu.(string)
+				default:
+					continue
+				}
+
+				if !match {
+					mismatch = true
+				}
+				score++
+			}
+		}
+
+		if mcname != nbtid.Data.(string) || mismatch {
+			continue
+		}
+
+		if score > best {
+			best = score
+			rslt = atom.Name
+		}
+	}
+
+	return
+}
+
+// glyph-tag names are made of lowercase letters only (see regexpTagDef and regexpTagRef, in blueprint/parse.go)
+//
+func lettersOnly(name string) (rslt string) {
+	rslt = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' { return r }
+		if r >= 'A' && r <= 'Z' { return r + ('a' - 'A') }
+		return -1
+	}, name)
+
+	if rslt == "" {
+		rslt = "tag"
+	}
+
+	return
+}
+
+// this makes a glyph-tag name unique by adding a letter-only suffix : a, b, ... z, aa, ab, ...
+//
+func uniqueTagName(base string, used map[string]bool) (rslt string) {
+	for n := 0; ; n++ {
+		suffix := ""
+		for m := n; ; m = (m / 26) - 1 {
+			suffix = string(rune('a' + (m % 26))) + suffix
+			if m < 26 { break }
+		}
+
+		rslt = base + suffix
+		if !used[rslt] {
+			used[rslt] = true
+			return
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// orientation : rendering a blueprint turned, or mirrored
+//
+// a blueprint is written facing one way, but can be built facing any way :  -mirror reverses it west to east ('x') or
+// north to south ('z'), and then -rotate turns it clockwise, as seen from above, by 90, 180 or 270 degrees;  either way,
+// -X, -Y, -Z is still the west, bottom, north corner of what is built, unless -origin names another point of it
+//
+// moving the glyphs is the easy part;  a block that faces some way, such as stairs or a ladder, has to be turned, too, and
+// which bits of its data value say which way it faces depends on the kind of block;  the legend names, as its 'orient',
+// which of the schemes below a glyph's block uses
+//
+
+// an orientation scheme is how a kind of block keeps, in the bits of its data value under mask, which way it faces; dirs
+// is the direction of each of those values, or "" for one that is not a direction (e.g., a torch standing on the floor)
+//
+// the schemes are named for the layout of the data value, not for a block :  'wall' is a block facing out from a wall,
+// as data values 2 through 5 (ladders, chests, furnaces), and 'horizontal' is one facing south, west, north or east, as
+// the low 2 bits (beds, anvils)
+//
+type orientScheme struct {
+	mask uint8
+	dirs []string
+}
+
+var orientSchemes = map[string]orientScheme{
+	"stairs":     {3, []string{"east", "west", "south", "north"}},
+	"torch":      {7, []string{"", "east", "west", "south", "north", ""}},
+	"wall":       {7, []string{"", "", "north", "south", "west", "east"}},
+	"door":       {3, []string{"east", "south", "west", "north"}},
+	"horizontal": {3, []string{"south", "west", "north", "east"}},
+	"trapdoor":   {3, []string{"north", "south", "west", "east"}},
+}
+
+// the directions, in clockwise order
+//
+var compass = []string{"north", "east", "south", "west"}
+
+// a placement is where in the world, and which way around, a blueprint is built :  the west, bottom, north corner of what
+// is built, how it is turned and mirrored, and the footprint of the blueprint as written
+//
+type placement struct {
+	x      int
+	y      int
+	z      int
+	turns  int
+	mirror string
+	width  int
+	depth  int
+}
+
+// newPlacement checks the -rotate and -mirror options, for a blueprint with the given footprint
+//
+func newPlacement(rotate int, mirror string, width int, depth int) (p placement, err error) {
+	if rotate % 90 != 0 || rotate < 0 || rotate > 270 {
+		return p, fmt.Errorf("unable to rotate by [%d] degrees; expected 0, 90, 180 or 270", rotate)
+	}
+	if mirror != "" && mirror != "x" && mirror != "z" {
+		return p, fmt.Errorf("unable to mirror along [%s]; expected 'x' or 'z'", mirror)
+	}
+
+	p = placement{turns: rotate / 90, mirror: mirror, width: width, depth: depth}
+
+	return
+}
+
+// footprint is how far what is built reaches, east and south; a quarter turn swaps the two
+//
+func (p placement) footprint() (width int, depth int) {
+	if p.turns % 2 == 1 {
+		return p.depth, p.width
+	}
+
+	return p.width, p.depth
+}
+
+// block is where in the world the glyph dx east, dy up and dz south of the blueprint's west, bottom, north corner, as
+// written, is built
+//
+func (p placement) block(dx int, dy int, dz int) (x int, y int, z int) {
+	width, depth := p.width, p.depth
+
+	if p.mirror == "x" {
+		dx = width - 1 - dx
+	}
+	if p.mirror == "z" {
+		dz = depth - 1 - dz
+	}
+
+	// each quarter turn clockwise takes the north edge to the east edge, and so on around
+	for turn := 0; turn < p.turns; turn++ {
+		dx, dz = depth - 1 - dz, dx
+		width, depth = depth, width
+	}
+
+	return p.x + dx, p.y + dy, p.z + dz
+}
+
+// facing is which way a block written facing dir faces as built
+//
+func (p placement) facing(dir string) string {
+	switch {
+	case p.mirror == "x" && dir == "east":
+		dir = "west"
+	case p.mirror == "x" && dir == "west":
+		dir = "east"
+	case p.mirror == "z" && dir == "north":
+		dir = "south"
+	case p.mirror == "z" && dir == "south":
+		dir = "north"
+	}
+
+	for indx, elem := range compass {
+		if elem == dir {
+			return compass[(indx + p.turns) % len(compass)]
+		}
+	}
+
+	return dir
+}
+
+// orientData turns the data value of a block that faces some way, by the orientation scheme its glyph names;  the other
+// bits of the data value, such as whether stairs are upside down, are left as they are
+//
+func (p placement) orientData(scheme string, data uint8) uint8 {
+	if scheme == "" || (p.turns == 0 && p.mirror == "") {
+		return data
+	}
+
+	// the upper half of a door has no facing of its own, only which side its hinge is on, which a mirror swaps
+	if scheme == "door" && data & 8 != 0 {
+		if p.mirror != "" {
+			data ^= 1
+		}
+		return data
+	}
+
+	// every orient in the legend was checked against the schemes as the legend was read, so this is only a safeguard
+	s, okay := orientSchemes[scheme]
+	if !okay {
+		return data
+	}
+	valu := int(data & s.mask)
+	if valu >= len(s.dirs) || s.dirs[valu] == "" {
+		return data
+	}
+
+	dir := p.facing(s.dirs[valu])
+	for indx, elem := range s.dirs {
+		if elem == dir {
+			return data &^ s.mask | uint8(indx)
+		}
+	}
+
+	return data
+}
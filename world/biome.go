@@ -0,0 +1,319 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/landru27/nbt"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Biomes
+//
+// a chunk keeps the biome of each of its columns in its Biomes array, which decides things such as the colour of grass and
+// leaves, and whether it rains or snows;  the array has been stored three ways :
+//
+//     to 1.12         : 256 bytes, one per column, indexed by ((z * 16) + x)
+//     1.13 and 1.14   : 256 ints, one per column, indexed the same way
+//     1.15 on         : 1024 ints, one per 4x4x4 cell, indexed by ((y / 4) * 16) + ((z / 4) * 4) + (x / 4); in this form,
+//                       a biome can only be set for a whole 4x4 cell of columns at a time
+//
+// the biome ids themselves are the same throughout
+//
+
+// the names of the biomes, by their names before 1.13 and their names since, so that a blueprint can use either
+//
+var biomeIDs = map[string]uint8{
+	"ocean":                            0,
+	"plains":                           1,
+	"desert":                           2,
+	"extreme_hills":                    3,
+	"mountains":                        3,
+	"forest":                           4,
+	"taiga":                            5,
+	"swampland":                        6,
+	"swamp":                            6,
+	"river":                            7,
+	"hell":                             8,
+	"nether":                           8,
+	"sky":                              9,
+	"the_end":                          9,
+	"frozen_ocean":                     10,
+	"frozen_river":                     11,
+	"ice_flats":                        12,
+	"snowy_tundra":                     12,
+	"ice_mountains":                    13,
+	"snowy_mountains":                  13,
+	"mushroom_island":                  14,
+	"mushroom_fields":                  14,
+	"mushroom_island_shore":            15,
+	"mushroom_field_shore":             15,
+	"beaches":                          16,
+	"beach":                            16,
+	"desert_hills":                     17,
+	"forest_hills":                     18,
+	"wooded_hills":                     18,
+	"taiga_hills":                      19,
+	"smaller_extreme_hills":            20,
+	"mountain_edge":                    20,
+	"jungle":                           21,
+	"jungle_hills":                     22,
+	"jungle_edge":                      23,
+	"deep_ocean":                       24,
+	"stone_beach":                      25,
+	"stone_shore":                      25,
+	"cold_beach":                       26,
+	"snowy_beach":                      26,
+	"birch_forest":                     27,
+	"birch_forest_hills":               28,
+	"roofed_forest":                    29,
+	"dark_forest":                      29,
+	"taiga_cold":                       30,
+	"snowy_taiga":                      30,
+	"taiga_cold_hills":                 31,
+	"snowy_taiga_hills":                31,
+	"redwood_taiga":                    32,
+	"giant_tree_taiga":                 32,
+	"redwood_taiga_hills":              33,
+	"giant_tree_taiga_hills":           33,
+	"extreme_hills_with_trees":         34,
+	"wooded_mountains":                 34,
+	"savanna":                          35,
+	"savanna_rock":                     36,
+	"savanna_plateau":                  36,
+	"mesa":                             37,
+	"badlands":                         37,
+	"mesa_rock":                        38,
+	"wooded_badlands_plateau":          38,
+	"mesa_clear_rock":                  39,
+	"badlands_plateau":                 39,
+	"small_end_islands":                40,
+	"end_midlands":                     41,
+	"end_highlands":                    42,
+	"end_barrens":                      43,
+	"warm_ocean":                       44,
+	"lukewarm_ocean":                   45,
+	"cold_ocean":                       46,
+	"deep_warm_ocean":                  47,
+	"deep_lukewarm_ocean":              48,
+	"deep_cold_ocean":                  49,
+	"deep_frozen_ocean":                50,
+	"void":                             127,
+	"the_void":                         127,
+	"mutated_plains":                   129,
+	"sunflower_plains":                 129,
+	"mutated_desert":                   130,
+	"desert_lakes":                     130,
+	"mutated_extreme_hills":            131,
+	"gravelly_mountains":               131,
+	"mutated_forest":                   132,
+	"flower_forest":                    132,
+	"mutated_taiga":                    133,
+	"taiga_mountains":                  133,
+	"mutated_swampland":                134,
+	"swamp_hills":                      134,
+	"mutated_ice_flats":                140,
+	"ice_spikes":                       140,
+	"mutated_jungle":                   149,
+	"modified_jungle":                  149,
+	"mutated_jungle_edge":              151,
+	"modified_jungle_edge":             151,
+	"mutated_birch_forest":             155,
+	"tall_birch_forest":                155,
+	"mutated_birch_forest_hills":       156,
+	"tall_birch_hills":                 156,
+	"mutated_roofed_forest":            157,
+	"dark_forest_hills":                157,
+	"mutated_taiga_cold":               158,
+	"snowy_taiga_mountains":            158,
+	"mutated_redwood_taiga":            160,
+	"giant_spruce_taiga":               160,
+	"mutated_redwood_taiga_hills":      161,
+	"giant_spruce_taiga_hills":         161,
+	"mutated_extreme_hills_with_trees": 162,
+	"modified_gravelly_mountains":      162,
+	"mutated_savanna":                  163,
+	"shattered_savanna":                163,
+	"mutated_savanna_rock":             164,
+	"shattered_savanna_plateau":        164,
+	"mutated_mesa":                     165,
+	"eroded_badlands":                  165,
+	"mutated_mesa_rock":                166,
+	"modified_wooded_badlands_plateau": 166,
+	"mutated_mesa_clear_rock":          167,
+	"modified_badlands_plateau":        167,
+	"bamboo_jungle":                    168,
+	"bamboo_jungle_hills":              169,
+	"soul_sand_valley":                 170,
+	"crimson_forest":                   171,
+	"warped_forest":                    172,
+	"basalt_deltas":                    173,
+}
+
+// BiomeID looks up a biome by name (e.g., 'desert' or 'minecraft:desert'), or takes it as a number
+//
+func BiomeID(name string) (id uint8, err error) {
+	if valu, errNum := strconv.ParseUint(name, 10, 8); errNum == nil {
+		id = uint8(valu)
+		return
+	}
+
+	id, okay := biomeIDs[strings.TrimPrefix(name, "minecraft:")]
+	if !okay {
+		err = fmt.Errorf("unknown biome [%s]", name)
+	}
+
+	return
+}
+
+// EditBiome sets the biome of the column at x, z;  columns in chunks not yet generated are skipped, as Minecraft will
+// decide their biomes itself when it generates them, and so are those in chunks whose Biomes array is missing or not one
+// of the sizes above, as Minecraft treats those chunks the same way
+//
+func (w *MCWorld) EditBiome(x int, z int, id uint8) (err error) {
+	rgn, err := w.LoadRegion(x, z)
+	if err != nil {
+		return
+	}
+
+	// calculate the in-region chunk coordinates and chunkdata index
+	cx := int(math.Floor(float64(x) / 16.0))
+	cz := int(math.Floor(float64(z) / 16.0))
+	indxChunk := ((cz - (rgn.RZ * 32)) * 32) + (cx - (rgn.RX * 32))
+
+	chnk := &rgn.Chunks[indxChunk]
+	err = w.DecodeChunk(chnk)
+	if err != nil {
+		return
+	}
+	if !chnk.Decoded {
+		w.Stats.BiomeEditsSkipped++
+		return
+	}
+
+	dataBiomes := chnk.ChunkDataRefs["Biomes"]
+	indxColumn := ((z - (cz * 16)) * 16) + (x - (cx * 16))
+
+	column := getBiomeColumn(dataBiomes, indxColumn)
+	if column == nil {
+		w.Stats.BiomeEditsSkipped++
+		return
+	}
+
+	// record what is here now, so that this edit can be undone
+	if w.Journal != nil {
+		w.Journal.Record(MCJournalRecord{Kind: "biome", X: x, Z: z, Biomes: append([]uint8{}, column...)})
+	}
+
+	if w.Plan != nil {
+		entry := newMCEditPlanEntry("biome", x, 0, z)
+		entry.OldID = uint16(column[0])
+		entry.NewID = uint16(id)
+		w.Plan.Record(entry)
+	}
+
+	for indx := range column {
+		column[indx] = id
+	}
+	setBiomeColumn(dataBiomes, indxColumn, column)
+
+	rgn.Dirty = true
+	w.Stats.BiomeEdits++
+
+	return
+}
+
+// GetBiome returns the biome of the column at x, z (since 1.15, the biome at the bottom of the column);  as with EditBiome,
+// a chunk not yet generated, or without a usable Biomes array, has no biome to return, and is taken to be 0
+//
+func (w *MCWorld) GetBiome(x int, z int) (id uint8, err error) {
+	rgn, err := w.LoadRegion(x, z)
+	if err != nil {
+		return
+	}
+
+	cx := int(math.Floor(float64(x) / 16.0))
+	cz := int(math.Floor(float64(z) / 16.0))
+	indxChunk := ((cz - (rgn.RZ * 32)) * 32) + (cx - (rgn.RX * 32))
+
+	chnk := &rgn.Chunks[indxChunk]
+	err = w.DecodeChunk(chnk)
+	if err != nil || !chnk.Decoded {
+		return
+	}
+
+	indxColumn := ((z - (cz * 16)) * 16) + (x - (cx * 16))
+
+	if column := getBiomeColumn(chnk.ChunkDataRefs["Biomes"], indxColumn); column != nil {
+		id = column[0]
+	}
+
+	return
+}
+
+// a column's biomes, from the bottom up : just the one, or, since 1.15, one for each of the 64 cells of the column;  nil if
+// the chunk has no Biomes array, or one of a size that is none of those above
+//
+func getBiomeColumn(dataBiomes *nbt.NBT, indxColumn int) (column []uint8) {
+	if dataBiomes == nil {
+		return
+	}
+
+	switch biomes := dataBiomes.Data.(type) {
+	case []byte:
+		if len(biomes) != 256 {
+			break
+		}
+		column = []uint8{biomes[indxColumn]}
+
+	case []int32:
+		if len(biomes) == 256 {
+			column = []uint8{uint8(biomes[indxColumn])}
+			break
+		}
+		if len(biomes) != 1024 {
+			break
+		}
+
+		indxCell := (((indxColumn / 16) / 4) * 4) + ((indxColumn % 16) / 4)
+		for indx := indxCell; indx < len(biomes); indx += 16 {
+			column = append(column, uint8(biomes[indx]))
+		}
+	}
+
+	return
+}
+
+func setBiomeColumn(dataBiomes *nbt.NBT, indxColumn int, column []uint8) {
+	if len(column) == 0 {
+		return
+	}
+
+	switch biomes := dataBiomes.Data.(type) {
+	case []byte:
+		if len(biomes) == 256 {
+			biomes[indxColumn] = column[0]
+		}
+
+	case []int32:
+		if len(biomes) == 256 {
+			biomes[indxColumn] = int32(column[0])
+			break
+		}
+		if len(biomes) != 1024 {
+			break
+		}
+
+		indxCell := (((indxColumn / 16) / 4) * 4) + ((indxColumn % 16) / 4)
+		for indx, valu := range column {
+			if (indxCell + (indx * 16)) < len(biomes) {
+				biomes[indxCell + (indx * 16)] = int32(valu)
+			}
+		}
+	}
+}
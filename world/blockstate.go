@@ -0,0 +1,1310 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// MCBlockState
+//
+// since 1.13, Minecraft names blocks rather than numbering them : a block-state is a namespaced block name, plus whatever
+// properties that kind of block has, e.g., 'minecraft:oak_stairs[facing=east,half=bottom]';  blueprints, on the other
+// hand, are written in terms of the numeric id and data values of earlier versions, so we translate between the two
+//
+// the translation follows Minecraft's own conversion of worlds to 1.13 (the 'flattening'); properties that the id and data
+// never said anything about (e.g., whether a fence connects to its neighbors) are left for Minecraft to fill in, and so are
+// left out here
+//
+type MCBlockState struct {
+	Name       string
+	Properties map[string]string
+}
+
+// ErrNoLegacyBlock is returned when a block-state has no equivalent id and data, as with blocks added after 1.12
+//
+var ErrNoLegacyBlock = errors.New("no legacy block id and data for this block-state")
+
+// the block-state string is the same one Minecraft uses in its commands; properties are sorted by name, so that the same
+// block-state always gives the same string
+//
+func (s MCBlockState) String() (rslt string) {
+	rslt = s.Name
+
+	if len(s.Properties) > 0 {
+		props := make([]string, 0, len(s.Properties))
+		for _, key := range sortedKeys(s.Properties) {
+			props = append(props, fmt.Sprintf("%s=%s", key, s.Properties[key]))
+		}
+		rslt += "[" + strings.Join(props, ",") + "]"
+	}
+
+	return
+}
+
+func sortedKeys(m map[string]string) (keys []string) {
+	keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return
+}
+
+// ParseBlockState reads a block-state string, such as String writes; the 'minecraft:' namespace may be left off
+//
+func ParseBlockState(str string) (s MCBlockState, err error) {
+	name := str
+	props := ""
+	if indx := strings.Index(str, "["); indx >= 0 {
+		if !strings.HasSuffix(str, "]") {
+			err = fmt.Errorf("malformed block-state [%s]", str)
+			return
+		}
+		name = str[:indx]
+		props = str[indx + 1:len(str) - 1]
+	}
+
+	if name == "" {
+		err = fmt.Errorf("malformed block-state [%s]", str)
+		return
+	}
+	if !strings.Contains(name, ":") {
+		name = "minecraft:" + name
+	}
+
+	s = MCBlockState{Name: name}
+	if props != "" {
+		s.Properties = make(map[string]string, 0)
+		for _, prop := range strings.Split(props, ",") {
+			kv := strings.SplitN(prop, "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				err = fmt.Errorf("malformed block-state property [%s] in [%s]", prop, str)
+				return
+			}
+			s.Properties[kv[0]] = kv[1]
+		}
+	}
+
+	return
+}
+
+// air comes in more than one kind since 1.13, but they are all equally empty
+//
+func (s MCBlockState) isAir() bool {
+	return s.Name == "minecraft:air" || s.Name == "minecraft:cave_air" || s.Name == "minecraft:void_air"
+}
+
+// a few blocks were renamed after 1.13 (all in 18w43a, on the way to 1.14), so that the names written depend on the
+// DataVersion of the chunk they are written into;  the translation tables below use the 1.13 names
+//
+const dataVersionRenames = 1901
+
+var blockRenames = map[string]string{
+	"minecraft:sign":       "minecraft:oak_sign",
+	"minecraft:wall_sign":  "minecraft:oak_wall_sign",
+	"minecraft:stone_slab": "minecraft:smooth_stone_slab",
+}
+
+func renameBlock(s MCBlockState, dataVersion int) (rslt MCBlockState) {
+	rslt = s
+
+	if dataVersion >= dataVersionRenames {
+		if name, okay := blockRenames[s.Name]; okay {
+			rslt.Name = name
+		}
+	}
+
+	return
+}
+
+func unrenameBlock(s MCBlockState, dataVersion int) (rslt MCBlockState) {
+	rslt = s
+
+	if dataVersion >= dataVersionRenames {
+		for old, name := range blockRenames {
+			if s.Name == name {
+				rslt.Name = old
+			}
+		}
+		// in 1.14, the name 'stone_slab' was given to a new slab of plain stone, which has no legacy equivalent
+		if s.Name == "minecraft:stone_slab" {
+			rslt.Name = "minecraft:plain_stone_slab"
+		}
+	}
+
+	return
+}
+
+// LegacyBlockState translates a block id and data value into the block-state that Minecraft would convert it into, for a
+// chunk of the given DataVersion; as Minecraft does, data values that an id never used are taken as 0, and ids that were
+// never used are taken as air
+//
+func LegacyBlockState(id uint16, data uint8, dataVersion int) (s MCBlockState) {
+	s, okay := legacyBlockState(id, data)
+	if !okay {
+		s, okay = legacyBlockState(id, 0)
+	}
+	if !okay {
+		s = MCBlockState{Name: "minecraft:air"}
+	}
+
+	s = renameBlock(s, dataVersion)
+
+	return
+}
+
+// BlockStateLegacy translates a block-state back into a block id and data value; where the block-state has properties
+// that the data value has no room for (e.g., the shape of a staircase), the closest match is used
+//
+func BlockStateLegacy(s MCBlockState, dataVersion int) (id uint16, data uint8, err error) {
+	s = unrenameBlock(s, dataVersion)
+
+	if s.isAir() {
+		return
+	}
+
+	legacyIndxOnce.Do(buildLegacyIndx)
+
+	// which half of a door, double plant, staircase or the like the block is outweighs every other property, so that
+	// the upper half of a door, say, is never taken for a lower half that happens to share more of its properties
+	best := -1
+	for _, elem := range legacyIndx[s.Name] {
+		matched := 0
+		mismatched := false
+		for key, valu := range elem.state.Properties {
+			if s.Properties[key] != valu {
+				mismatched = true
+				continue
+			}
+			matched++
+			if key == "half" {
+				matched += len(s.Properties)
+			}
+		}
+		if matched > best {
+			best = matched
+			id = elem.id
+			data = elem.data
+		}
+		if !mismatched {
+			break
+		}
+	}
+
+	if best < 0 {
+		err = ErrNoLegacyBlock
+	}
+
+	return
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// the translation tables
+//
+// rather than list all 4096 combinations of id and data, each legacy id names a function that works out the block-state
+// from the data value, using the handful of ways that data values were laid out;  the reverse translation is built by
+// running every id and data value through these, except for those that Minecraft converted into the same block-state as
+// another (flowing water and lava are simply water and lava, once converted, and tall grass of the dead bush kind is
+// simply a dead bush), which are translated back as the other
+
+var legacyAliases = map[uint16]bool{8: true, 10: true}
+
+type legacyEntry struct {
+	id    uint16
+	data  uint8
+	state MCBlockState
+}
+
+var legacyIndx map[string][]legacyEntry
+var legacyIndxOnce sync.Once
+
+func buildLegacyIndx() {
+	legacyIndx = make(map[string][]legacyEntry, 0)
+
+	for id := 0; id < 256; id++ {
+		for data := 0; data < 16; data++ {
+			if legacyAliases[uint16(id)] || (id == 31 && data == 0) {
+				continue
+			}
+
+			s, okay := legacyBlockState(uint16(id), uint8(data))
+			if !okay || s.isAir() {
+				continue
+			}
+			legacyIndx[s.Name] = append(legacyIndx[s.Name], legacyEntry{uint16(id), uint8(data), s})
+		}
+	}
+
+	// the upper half of every kind of double plant is translated as a sunflower's, and named after its lower half later,
+	// so each kind's upper half goes back to the same id and data
+	for _, name := range doublePlants[1:] {
+		s := state("minecraft:" + name, "half", "upper")
+		legacyIndx[s.Name] = append(legacyIndx[s.Name], legacyEntry{175, 8, s})
+	}
+}
+
+func legacyBlockState(id uint16, data uint8) (s MCBlockState, okay bool) {
+	fn, okay := legacyBlocks[id]
+	if !okay {
+		return
+	}
+
+	s, okay = fn(data)
+	if okay && !strings.Contains(s.Name, ":") {
+		s.Name = "minecraft:" + s.Name
+	}
+
+	return
+}
+
+type legacyFn func(data uint8) (MCBlockState, bool)
+
+func state(name string, props ...string) (s MCBlockState) {
+	s = MCBlockState{Name: name}
+
+	if len(props) > 0 {
+		s.Properties = make(map[string]string, len(props) / 2)
+		for indx := 0; indx + 1 < len(props); indx += 2 {
+			s.Properties[props[indx]] = props[indx + 1]
+		}
+	}
+
+	return
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// the kinds of double plant, by the data value of their lower half;  the upper half only says that it is an upper half (with
+// the direction a sunflower faces in its other bits), and is whichever kind the lower half below it is (see pairHalves)
+var doublePlants = []string{"sunflower", "lilac", "tall_grass", "large_fern", "rose_bush", "peony"}
+
+func isDoublePlant(name string) bool {
+	for _, elem := range doublePlants {
+		if name == "minecraft:" + elem {
+			return true
+		}
+	}
+	return false
+}
+
+// withProperties copies a block-state, with the given properties set to the given values
+func withProperties(s MCBlockState, props ...string) (rslt MCBlockState) {
+	rslt = MCBlockState{Name: s.Name, Properties: make(map[string]string, len(s.Properties))}
+	for key, valu := range s.Properties {
+		rslt.Properties[key] = valu
+	}
+	for indx := 0; indx + 1 < len(props); indx += 2 {
+		rslt.Properties[props[indx]] = props[indx + 1]
+	}
+
+	return
+}
+
+// the orderings of facing directions that data values were used for, depending on the block
+var facing6 = []string{"down", "up", "north", "south", "west", "east"}
+var facingSWNE = []string{"south", "west", "north", "east"}
+var facingEWSN = []string{"east", "west", "south", "north"}
+var facingESWN = []string{"east", "south", "west", "north"}
+var colors = []string{"white", "orange", "magenta", "light_blue", "yellow", "lime", "pink", "gray", "light_gray", "cyan", "purple", "blue", "brown", "green", "red", "black"}
+var woods = []string{"oak", "spruce", "birch", "jungle", "acacia", "dark_oak"}
+
+// a block that ignores its data value
+func plain(name string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		return state(name), data == 0
+	}
+}
+
+// a block whose data value picks one of several kinds
+func variants(names ...string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		if int(data) >= len(names) || names[data] == "" {
+			return MCBlockState{}, false
+		}
+		return state(names[data]), true
+	}
+}
+
+func colored(suffix string) legacyFn {
+	names := make([]string, 16)
+	for indx, color := range colors {
+		names[indx] = color + "_" + suffix
+	}
+	return variants(names...)
+}
+
+// a block whose data value is a single numbered property, e.g., the age of a crop
+func numbered(name string, prop string, max uint8, offset int) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		if data > max {
+			return MCBlockState{}, false
+		}
+		return state(name, prop, fmt.Sprintf("%d", int(data) + offset)), true
+	}
+}
+
+// a block facing north, south, west or east, as data values 2 through 5
+func facing2to5(name string, props ...string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		if data < 2 || data > 5 {
+			return MCBlockState{}, false
+		}
+		return state(name, append([]string{"facing", facing6[data]}, props...)...), true
+	}
+}
+
+// a block facing any of the six directions, with a flag in the high bit
+func facing0to5(name string, flag string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		if data & 7 > 5 {
+			return MCBlockState{}, false
+		}
+		if flag == "" {
+			if data & 8 != 0 {
+				return MCBlockState{}, false
+			}
+			return state(name, "facing", facing6[data & 7]), true
+		}
+		return state(name, "facing", facing6[data & 7], flag, boolString(data & 8 != 0)), true
+	}
+}
+
+// a block facing south, west, north or east, as the low 2 bits, with other properties in the high 2 bits
+func facing4(name string, order []string, high func(data uint8) ([]string, bool)) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		props := []string{"facing", order[data & 3]}
+		if high == nil {
+			return state(name, props...), data < 4
+		}
+		more, okay := high(data)
+		return state(name, append(props, more...)...), okay
+	}
+}
+
+func stairs(name string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		if data > 7 {
+			return MCBlockState{}, false
+		}
+		half := "bottom"
+		if data & 4 != 0 {
+			half = "top"
+		}
+		return state(name, "facing", facingEWSN[data & 3], "half", half), true
+	}
+}
+
+func slab(names ...string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		if int(data & 7) >= len(names) || names[data & 7] == "" {
+			return MCBlockState{}, false
+		}
+		kind := "bottom"
+		if data & 8 != 0 {
+			kind = "top"
+		}
+		return state(names[data & 7], "type", kind), true
+	}
+}
+
+func doubleSlab(names ...string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		if int(data) >= len(names) || names[data] == "" {
+			return MCBlockState{}, false
+		}
+		if !strings.HasSuffix(names[data], "_slab") {
+			return state(names[data]), true
+		}
+		return state(names[data], "type", "double"), true
+	}
+}
+
+// the lower half of a door knows which way it faces and whether it is open; the upper half knows which side its hinge is
+// on and whether it is powered;  neither half can be translated on its own, so each is given placeholders for what it does
+// not know, which pairHalves then fills in from the other half, as Minecraft's own conversion does
+func door(name string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		if data & 8 == 0 {
+			return state(name, "half", "lower", "facing", facingESWN[data & 3], "open", boolString(data & 4 != 0), "hinge", "left", "powered", "false"), true
+		}
+		if data > 11 {
+			return MCBlockState{}, false
+		}
+		hinge := "left"
+		if data & 1 != 0 {
+			hinge = "right"
+		}
+		return state(name, "half", "upper", "facing", "east", "open", "false", "hinge", hinge, "powered", boolString(data & 2 != 0)), true
+	}
+}
+
+func trapdoor(name string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		half := "bottom"
+		if data & 8 != 0 {
+			half = "top"
+		}
+		return state(name, "facing", []string{"north", "south", "west", "east"}[data & 3], "open", boolString(data & 4 != 0), "half", half), true
+	}
+}
+
+func fenceGate(name string) legacyFn {
+	return facing4(name, facingSWNE, func(data uint8) ([]string, bool) {
+		return []string{"open", boolString(data & 4 != 0)}, data < 8
+	})
+}
+
+func torch(name string, wall string, props ...string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		switch data {
+		case 1, 2, 3, 4:
+			return state(wall, append([]string{"facing", []string{"", "east", "west", "south", "north"}[data]}, props...)...), true
+		case 5:
+			return state(name, props...), true
+		}
+		return MCBlockState{}, false
+	}
+}
+
+func button(name string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		powered := boolString(data & 8 != 0)
+		switch data & 7 {
+		case 0:
+			return state(name, "face", "ceiling", "facing", "north", "powered", powered), true
+		case 1, 2, 3, 4:
+			return state(name, "face", "wall", "facing", []string{"", "east", "west", "south", "north"}[data & 7], "powered", powered), true
+		case 5:
+			return state(name, "face", "floor", "facing", "north", "powered", powered), true
+		}
+		return MCBlockState{}, false
+	}
+}
+
+func lever() legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		powered := boolString(data & 8 != 0)
+		switch data & 7 {
+		case 0:
+			return state("lever", "face", "ceiling", "facing", "west", "powered", powered), true
+		case 1, 2, 3, 4:
+			return state("lever", "face", "wall", "facing", []string{"", "east", "west", "south", "north"}[data & 7], "powered", powered), true
+		case 5:
+			return state("lever", "face", "floor", "facing", "north", "powered", powered), true
+		case 6:
+			return state("lever", "face", "floor", "facing", "west", "powered", powered), true
+		case 7:
+			return state("lever", "face", "ceiling", "facing", "north", "powered", powered), true
+		}
+		return MCBlockState{}, false
+	}
+}
+
+func rail(name string, powerable bool) legacyFn {
+	shapes := []string{"north_south", "east_west", "ascending_east", "ascending_west", "ascending_north", "ascending_south", "south_east", "south_west", "north_west", "north_east"}
+	return func(data uint8) (MCBlockState, bool) {
+		if !powerable {
+			if int(data) >= len(shapes) {
+				return MCBlockState{}, false
+			}
+			return state(name, "shape", shapes[data]), true
+		}
+		if data & 7 > 5 {
+			return MCBlockState{}, false
+		}
+		return state(name, "shape", shapes[data & 7], "powered", boolString(data & 8 != 0)), true
+	}
+}
+
+// logs lie along an axis, given by the high 2 bits; the 4th axis value means bark on all sides
+func logs(names ...string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		if int(data & 3) >= len(names) {
+			return MCBlockState{}, false
+		}
+		name := names[data & 3]
+		if data >> 2 == 3 {
+			return state(strings.Replace(name, "_log", "_wood", 1), "axis", "y"), true
+		}
+		return state(name, "axis", []string{"y", "x", "z"}[data >> 2]), true
+	}
+}
+
+func leaves(names ...string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		if int(data & 3) >= len(names) {
+			return MCBlockState{}, false
+		}
+		return state(names[data & 3], "persistent", boolString(data & 4 != 0)), true
+	}
+}
+
+// pillars lie along an axis, given by data values 0, 4 and 8
+func pillar(name string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		if data & 3 != 0 || data > 8 {
+			return MCBlockState{}, false
+		}
+		return state(name, "axis", []string{"y", "x", "z"}[data >> 2]), true
+	}
+}
+
+func liquid(name string) legacyFn {
+	return numbered(name, "level", 15, 0)
+}
+
+func bed() legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		part := "foot"
+		if data & 8 != 0 {
+			part = "head"
+		}
+		return state("red_bed", "facing", facingSWNE[data & 3], "occupied", boolString(data & 4 != 0), "part", part), true
+	}
+}
+
+func piston(name string) legacyFn {
+	return facing0to5(name, "extended")
+}
+
+func repeater(powered bool) legacyFn {
+	return facing4("repeater", facingSWNE, func(data uint8) ([]string, bool) {
+		return []string{"delay", fmt.Sprintf("%d", (data >> 2) + 1), "locked", "false", "powered", boolString(powered)}, true
+	})
+}
+
+func comparator(powered bool) legacyFn {
+	return facing4("comparator", facingSWNE, func(data uint8) ([]string, bool) {
+		mode := "compare"
+		if data & 4 != 0 {
+			mode = "subtract"
+		}
+		return []string{"mode", mode, "powered", boolString(powered || data & 8 != 0)}, true
+	})
+}
+
+func mushroomBlock(name string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		switch data {
+		case 10, 15:
+			return state("mushroom_stem"), true
+		case 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 14:
+			return state(name), true
+		}
+		return MCBlockState{}, false
+	}
+}
+
+func shulkerBox(color string) legacyFn {
+	return func(data uint8) (MCBlockState, bool) {
+		if data > 5 {
+			return MCBlockState{}, false
+		}
+		return state(color + "_shulker_box", "facing", facing6[data]), true
+	}
+}
+
+func glazedTerracotta(color string) legacyFn {
+	return facing4(color + "_glazed_terracotta", facingSWNE, nil)
+}
+
+var legacyBlocks = map[uint16]legacyFn{
+	0:   plain("air"),
+	1:   variants("stone", "granite", "polished_granite", "diorite", "polished_diorite", "andesite", "polished_andesite"),
+	2:   func(data uint8) (MCBlockState, bool) { return state("grass_block", "snowy", "false"), data == 0 },
+	3:   variants("dirt", "coarse_dirt", "podzol"),
+	4:   plain("cobblestone"),
+	5:   variants("oak_planks", "spruce_planks", "birch_planks", "jungle_planks", "acacia_planks", "dark_oak_planks"),
+	6:   func(data uint8) (MCBlockState, bool) {
+		if data & 7 > 5 {
+			return MCBlockState{}, false
+		}
+		return state(woods[data & 7] + "_sapling", "stage", fmt.Sprintf("%d", data >> 3)), true
+	},
+	7:   plain("bedrock"),
+	8:   liquid("water"),
+	9:   liquid("water"),
+	10:  liquid("lava"),
+	11:  liquid("lava"),
+	12:  variants("sand", "red_sand"),
+	13:  plain("gravel"),
+	14:  plain("gold_ore"),
+	15:  plain("iron_ore"),
+	16:  plain("coal_ore"),
+	17:  logs("oak_log", "spruce_log", "birch_log", "jungle_log"),
+	18:  leaves("oak_leaves", "spruce_leaves", "birch_leaves", "jungle_leaves"),
+	19:  variants("sponge", "wet_sponge"),
+	20:  plain("glass"),
+	21:  plain("lapis_ore"),
+	22:  plain("lapis_block"),
+	23:  facing0to5("dispenser", "triggered"),
+	24:  variants("sandstone", "chiseled_sandstone", "cut_sandstone"),
+	25:  plain("note_block"),
+	26:  bed(),
+	27:  rail("powered_rail", true),
+	28:  rail("detector_rail", true),
+	29:  piston("sticky_piston"),
+	30:  plain("cobweb"),
+	31:  variants("dead_bush", "grass", "fern"),
+	32:  plain("dead_bush"),
+	33:  piston("piston"),
+	34:  func(data uint8) (MCBlockState, bool) {
+		if data & 7 > 5 {
+			return MCBlockState{}, false
+		}
+		kind := "normal"
+		if data & 8 != 0 {
+			kind = "sticky"
+		}
+		return state("piston_head", "facing", facing6[data & 7], "type", kind), true
+	},
+	35:  colored("wool"),
+	36:  facing0to5("moving_piston", ""),
+	37:  plain("dandelion"),
+	38:  variants("poppy", "blue_orchid", "allium", "azure_bluet", "red_tulip", "orange_tulip", "white_tulip", "pink_tulip", "oxeye_daisy"),
+	39:  plain("brown_mushroom"),
+	40:  plain("red_mushroom"),
+	41:  plain("gold_block"),
+	42:  plain("iron_block"),
+	43:  doubleSlab("stone_slab", "sandstone_slab", "petrified_oak_slab", "cobblestone_slab", "brick_slab", "stone_brick_slab", "nether_brick_slab", "quartz_slab", "smooth_stone", "smooth_sandstone", "", "", "", "", "", "smooth_quartz"),
+	44:  slab("stone_slab", "sandstone_slab", "petrified_oak_slab", "cobblestone_slab", "brick_slab", "stone_brick_slab", "nether_brick_slab", "quartz_slab"),
+	45:  plain("bricks"),
+	46:  plain("tnt"),
+	47:  plain("bookshelf"),
+	48:  plain("mossy_cobblestone"),
+	49:  plain("obsidian"),
+	50:  torch("torch", "wall_torch"),
+	51:  numbered("fire", "age", 15, 0),
+	52:  plain("spawner"),
+	53:  stairs("oak_stairs"),
+	54:  facing2to5("chest", "type", "single"),
+	55:  numbered("redstone_wire", "power", 15, 0),
+	56:  plain("diamond_ore"),
+	57:  plain("diamond_block"),
+	58:  plain("crafting_table"),
+	59:  numbered("wheat", "age", 7, 0),
+	60:  numbered("farmland", "moisture", 7, 0),
+	61:  facing2to5("furnace", "lit", "false"),
+	62:  facing2to5("furnace", "lit", "true"),
+	63:  numbered("sign", "rotation", 15, 0),
+	64:  door("oak_door"),
+	65:  facing2to5("ladder"),
+	66:  rail("rail", false),
+	67:  stairs("cobblestone_stairs"),
+	68:  facing2to5("wall_sign"),
+	69:  lever(),
+	70:  numbered("stone_pressure_plate", "powered", 1, 0),
+	71:  door("iron_door"),
+	72:  numbered("oak_pressure_plate", "powered", 1, 0),
+	73:  func(data uint8) (MCBlockState, bool) { return state("redstone_ore", "lit", "false"), data == 0 },
+	74:  func(data uint8) (MCBlockState, bool) { return state("redstone_ore", "lit", "true"), data == 0 },
+	75:  torch("redstone_torch", "redstone_wall_torch", "lit", "false"),
+	76:  torch("redstone_torch", "redstone_wall_torch", "lit", "true"),
+	77:  button("stone_button"),
+	78:  numbered("snow", "layers", 7, 1),
+	79:  plain("ice"),
+	80:  plain("snow_block"),
+	81:  numbered("cactus", "age", 15, 0),
+	82:  plain("clay"),
+	83:  numbered("sugar_cane", "age", 15, 0),
+	84:  func(data uint8) (MCBlockState, bool) { return state("jukebox", "has_record", boolString(data == 1)), data < 2 },
+	85:  plain("oak_fence"),
+	86:  facing4("carved_pumpkin", facingSWNE, nil),
+	87:  plain("netherrack"),
+	88:  plain("soul_sand"),
+	89:  plain("glowstone"),
+	90:  func(data uint8) (MCBlockState, bool) {
+		if data < 1 || data > 2 {
+			return MCBlockState{}, false
+		}
+		return state("nether_portal", "axis", []string{"", "x", "z"}[data]), true
+	},
+	91:  facing4("jack_o_lantern", facingSWNE, nil),
+	92:  numbered("cake", "bites", 6, 0),
+	93:  repeater(false),
+	94:  repeater(true),
+	95:  colored("stained_glass"),
+	96:  trapdoor("oak_trapdoor"),
+	97:  variants("infested_stone", "infested_cobblestone", "infested_stone_bricks", "infested_mossy_stone_bricks", "infested_cracked_stone_bricks", "infested_chiseled_stone_bricks"),
+	98:  variants("stone_bricks", "mossy_stone_bricks", "cracked_stone_bricks", "chiseled_stone_bricks"),
+	99:  mushroomBlock("brown_mushroom_block"),
+	100: mushroomBlock("red_mushroom_block"),
+	101: plain("iron_bars"),
+	102: plain("glass_pane"),
+	103: plain("melon"),
+	104: numbered("pumpkin_stem", "age", 7, 0),
+	105: numbered("melon_stem", "age", 7, 0),
+	106: func(data uint8) (MCBlockState, bool) {
+		return state("vine", "south", boolString(data & 1 != 0), "west", boolString(data & 2 != 0), "north", boolString(data & 4 != 0), "east", boolString(data & 8 != 0), "up", boolString(data == 0)), true
+	},
+	107: fenceGate("oak_fence_gate"),
+	108: stairs("brick_stairs"),
+	109: stairs("stone_brick_stairs"),
+	110: func(data uint8) (MCBlockState, bool) { return state("mycelium", "snowy", "false"), data == 0 },
+	111: plain("lily_pad"),
+	112: plain("nether_bricks"),
+	113: plain("nether_brick_fence"),
+	114: stairs("nether_brick_stairs"),
+	115: numbered("nether_wart", "age", 3, 0),
+	116: plain("enchanting_table"),
+	117: func(data uint8) (MCBlockState, bool) {
+		return state("brewing_stand", "has_bottle_0", boolString(data & 1 != 0), "has_bottle_1", boolString(data & 2 != 0), "has_bottle_2", boolString(data & 4 != 0)), data < 8
+	},
+	118: numbered("cauldron", "level", 3, 0),
+	119: plain("end_portal"),
+	120: facing4("end_portal_frame", facingSWNE, func(data uint8) ([]string, bool) {
+		return []string{"eye", boolString(data & 4 != 0)}, data < 8
+	}),
+	121: plain("end_stone"),
+	122: plain("dragon_egg"),
+	123: func(data uint8) (MCBlockState, bool) { return state("redstone_lamp", "lit", "false"), data == 0 },
+	124: func(data uint8) (MCBlockState, bool) { return state("redstone_lamp", "lit", "true"), data == 0 },
+	125: doubleSlab("oak_slab", "spruce_slab", "birch_slab", "jungle_slab", "acacia_slab", "dark_oak_slab"),
+	126: slab("oak_slab", "spruce_slab", "birch_slab", "jungle_slab", "acacia_slab", "dark_oak_slab"),
+	127: facing4("cocoa", facingSWNE, func(data uint8) ([]string, bool) {
+		return []string{"age", fmt.Sprintf("%d", data >> 2)}, data < 12
+	}),
+	128: stairs("sandstone_stairs"),
+	129: plain("emerald_ore"),
+	130: facing2to5("ender_chest"),
+	131: facing4("tripwire_hook", facingSWNE, func(data uint8) ([]string, bool) {
+		return []string{"attached", boolString(data & 4 != 0), "powered", boolString(data & 8 != 0)}, true
+	}),
+	132: func(data uint8) (MCBlockState, bool) {
+		return state("tripwire", "powered", boolString(data & 1 != 0), "attached", boolString(data & 4 != 0), "disarmed", boolString(data & 8 != 0)), data & 2 == 0
+	},
+	133: plain("emerald_block"),
+	134: stairs("spruce_stairs"),
+	135: stairs("birch_stairs"),
+	136: stairs("jungle_stairs"),
+	137: facing0to5("command_block", "conditional"),
+	138: plain("beacon"),
+	139: variants("cobblestone_wall", "mossy_cobblestone_wall"),
+	140: plain("flower_pot"),
+	141: numbered("carrots", "age", 7, 0),
+	142: numbered("potatoes", "age", 7, 0),
+	143: button("oak_button"),
+	144: func(data uint8) (MCBlockState, bool) {
+		switch data & 7 {
+		case 1:
+			return state("skeleton_skull", "rotation", "0"), true
+		case 2, 3, 4, 5:
+			return state("skeleton_wall_skull", "facing", facing6[data & 7]), true
+		}
+		return MCBlockState{}, false
+	},
+	145: func(data uint8) (MCBlockState, bool) {
+		if data > 11 {
+			return MCBlockState{}, false
+		}
+		return state([]string{"anvil", "chipped_anvil", "damaged_anvil"}[data >> 2], "facing", facingSWNE[data & 3]), true
+	},
+	146: facing2to5("trapped_chest", "type", "single"),
+	147: numbered("light_weighted_pressure_plate", "power", 15, 0),
+	148: numbered("heavy_weighted_pressure_plate", "power", 15, 0),
+	149: comparator(false),
+	150: comparator(true),
+	151: func(data uint8) (MCBlockState, bool) { return state("daylight_detector", "inverted", "false", "power", fmt.Sprintf("%d", data)), true },
+	152: plain("redstone_block"),
+	153: plain("nether_quartz_ore"),
+	154: func(data uint8) (MCBlockState, bool) {
+		if data & 7 == 1 || data & 7 > 5 {
+			return MCBlockState{}, false
+		}
+		return state("hopper", "facing", facing6[data & 7], "enabled", boolString(data & 8 == 0)), true
+	},
+	155: func(data uint8) (MCBlockState, bool) {
+		switch data {
+		case 0:
+			return state("quartz_block"), true
+		case 1:
+			return state("chiseled_quartz_block"), true
+		case 2, 3, 4:
+			return state("quartz_pillar", "axis", []string{"", "", "y", "x", "z"}[data]), true
+		}
+		return MCBlockState{}, false
+	},
+	156: stairs("quartz_stairs"),
+	157: rail("activator_rail", true),
+	158: facing0to5("dropper", "triggered"),
+	159: colored("terracotta"),
+	160: colored("stained_glass_pane"),
+	161: leaves("acacia_leaves", "dark_oak_leaves"),
+	162: logs("acacia_log", "dark_oak_log"),
+	163: stairs("acacia_stairs"),
+	164: stairs("dark_oak_stairs"),
+	165: plain("slime_block"),
+	166: plain("barrier"),
+	167: trapdoor("iron_trapdoor"),
+	168: variants("prismarine", "prismarine_bricks", "dark_prismarine"),
+	169: plain("sea_lantern"),
+	170: pillar("hay_block"),
+	171: colored("carpet"),
+	172: plain("terracotta"),
+	173: plain("coal_block"),
+	174: plain("packed_ice"),
+	175: func(data uint8) (MCBlockState, bool) {
+		if data & 8 != 0 {
+			return state(doublePlants[0], "half", "upper"), true
+		}
+		if int(data) >= len(doublePlants) {
+			return MCBlockState{}, false
+		}
+		return state(doublePlants[data], "half", "lower"), true
+	},
+	176: numbered("white_banner", "rotation", 15, 0),
+	177: facing2to5("white_wall_banner"),
+	178: func(data uint8) (MCBlockState, bool) { return state("daylight_detector", "inverted", "true", "power", fmt.Sprintf("%d", data)), true },
+	179: variants("red_sandstone", "chiseled_red_sandstone", "cut_red_sandstone"),
+	180: stairs("red_sandstone_stairs"),
+	181: func(data uint8) (MCBlockState, bool) {
+		switch data {
+		case 0:
+			return state("red_sandstone_slab", "type", "double"), true
+		case 8:
+			return state("smooth_red_sandstone"), true
+		}
+		return MCBlockState{}, false
+	},
+	182: slab("red_sandstone_slab"),
+	183: fenceGate("spruce_fence_gate"),
+	184: fenceGate("birch_fence_gate"),
+	185: fenceGate("jungle_fence_gate"),
+	186: fenceGate("dark_oak_fence_gate"),
+	187: fenceGate("acacia_fence_gate"),
+	188: plain("spruce_fence"),
+	189: plain("birch_fence"),
+	190: plain("jungle_fence"),
+	191: plain("dark_oak_fence"),
+	192: plain("acacia_fence"),
+	193: door("spruce_door"),
+	194: door("birch_door"),
+	195: door("jungle_door"),
+	196: door("acacia_door"),
+	197: door("dark_oak_door"),
+	198: facing0to5("end_rod", ""),
+	199: plain("chorus_plant"),
+	200: numbered("chorus_flower", "age", 5, 0),
+	201: plain("purpur_block"),
+	202: pillar("purpur_pillar"),
+	203: stairs("purpur_stairs"),
+	204: func(data uint8) (MCBlockState, bool) { return state("purpur_slab", "type", "double"), data == 0 },
+	205: slab("purpur_slab"),
+	206: plain("end_stone_bricks"),
+	207: numbered("beetroots", "age", 3, 0),
+	208: plain("grass_path"),
+	209: plain("end_gateway"),
+	210: facing0to5("repeating_command_block", "conditional"),
+	211: facing0to5("chain_command_block", "conditional"),
+	212: numbered("frosted_ice", "age", 3, 0),
+	213: plain("magma_block"),
+	214: plain("nether_wart_block"),
+	215: plain("red_nether_bricks"),
+	216: pillar("bone_block"),
+	217: plain("structure_void"),
+	218: facing0to5("observer", "powered"),
+	219: shulkerBox("white"),
+	220: shulkerBox("orange"),
+	221: shulkerBox("magenta"),
+	222: shulkerBox("light_blue"),
+	223: shulkerBox("yellow"),
+	224: shulkerBox("lime"),
+	225: shulkerBox("pink"),
+	226: shulkerBox("gray"),
+	227: shulkerBox("light_gray"),
+	228: shulkerBox("cyan"),
+	229: shulkerBox("purple"),
+	230: shulkerBox("blue"),
+	231: shulkerBox("brown"),
+	232: shulkerBox("green"),
+	233: shulkerBox("red"),
+	234: shulkerBox("black"),
+	235: glazedTerracotta("white"),
+	236: glazedTerracotta("orange"),
+	237: glazedTerracotta("magenta"),
+	238: glazedTerracotta("light_blue"),
+	239: glazedTerracotta("yellow"),
+	240: glazedTerracotta("lime"),
+	241: glazedTerracotta("pink"),
+	242: glazedTerracotta("gray"),
+	243: glazedTerracotta("light_gray"),
+	244: glazedTerracotta("cyan"),
+	245: glazedTerracotta("purple"),
+	246: glazedTerracotta("blue"),
+	247: glazedTerracotta("brown"),
+	248: glazedTerracotta("green"),
+	249: glazedTerracotta("red"),
+	250: glazedTerracotta("black"),
+	251: colored("concrete"),
+	252: colored("concrete_powder"),
+	255: func(data uint8) (MCBlockState, bool) {
+		if data > 3 {
+			return MCBlockState{}, false
+		}
+		return state("structure_block", "mode", []string{"save", "load", "corner", "data"}[data]), true
+	},
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// items
+//
+// items were flattened in 1.13 along with blocks :  before, an item is an id, such as 'minecraft:wool', and a Damage that,
+// for most items, picks one of several kinds, as a block's data value does (light gray wool is wool with a Damage of 8);
+// since, each kind has an id of its own ('minecraft:light_gray_wool'), and Damage is only how worn a tool, weapon or piece
+// of armour is
+//
+// an item that is also a block is translated by way of the block tables, with its Damage as the block's data value;  the
+// table below covers every other item, along with the few blocks whose item is not simply the block with data value 0
+
+// ErrNoLegacyItem is returned for an item id that no version before 1.13 had, or that has no one item id since (e.g., a
+// spawn egg, which since has an id for each kind of mob)
+//
+var ErrNoLegacyItem = errors.New("no item id since 1.13 for this item id and Damage")
+
+// LegacyItem translates an item id, as written before 1.13, and its Damage into the item id that Minecraft would convert
+// it into, for the given DataVersion; worn says whether the Damage is how worn the item is, to be kept in the item's tag,
+// rather than which kind of item it is;  as with blocks, a Damage that an item never used is taken as 0
+//
+func LegacyItem(name string, damage int16, dataVersion int) (id string, worn bool, err error) {
+	name = strings.TrimPrefix(name, "minecraft:")
+
+	if item, okay := legacyItems[name]; okay {
+		id = item.kinds[0]
+		if !item.worn && damage > 0 && int(damage) < len(item.kinds) && item.kinds[damage] != "" {
+			id = item.kinds[damage]
+		}
+		if rename, okay := itemRenames[id]; okay && dataVersion >= dataVersionRenames {
+			id = rename
+		}
+
+		return "minecraft:" + id, item.worn, nil
+	}
+
+	if blockID, okay := legacyBlockItems[name]; okay {
+		if damage < 0 || damage > 15 {
+			damage = 0
+		}
+		s := LegacyBlockState(blockID, uint8(damage), dataVersion)
+		if !s.isAir() {
+			return s.Name, false, nil
+		}
+	}
+
+	return "", false, ErrNoLegacyItem
+}
+
+// an item's id for each Damage, or for any Damage if it has only the one;  a worn item has only the one, its Damage being
+// how worn it is
+//
+type legacyItem struct {
+	kinds []string
+	worn  bool
+}
+
+func itemKinds(names ...string) legacyItem {
+	return legacyItem{kinds: names}
+}
+
+func itemWorn(name string) legacyItem {
+	return legacyItem{kinds: []string{name}, worn: true}
+}
+
+func itemColored(suffix string, reversed bool) legacyItem {
+	names := make([]string, 16)
+	for indx, color := range colors {
+		if reversed {
+			indx = 15 - indx
+		}
+		names[indx] = color + "_" + suffix
+	}
+	return itemKinds(names...)
+}
+
+// as with blocks, a few items were renamed after 1.13
+//
+var itemRenames = map[string]string{
+	"sign":             "oak_sign",
+	"rose_red":         "red_dye",
+	"dandelion_yellow": "yellow_dye",
+	"cactus_green":     "green_dye",
+}
+
+var legacyItems = map[string]legacyItem{
+	// blocks whose item is not simply the block with data value 0
+	"torch":          itemKinds("torch"),
+	"redstone_torch": itemKinds("redstone_torch"),
+	"chest":          itemKinds("chest"),
+	"trapped_chest":  itemKinds("trapped_chest"),
+	"ender_chest":    itemKinds("ender_chest"),
+	"furnace":        itemKinds("furnace"),
+	"ladder":         itemKinds("ladder"),
+	"anvil":          itemKinds("anvil", "chipped_anvil", "damaged_anvil"),
+
+	// the rest
+	"iron_shovel":            itemWorn("iron_shovel"),
+	"iron_pickaxe":           itemWorn("iron_pickaxe"),
+	"iron_axe":               itemWorn("iron_axe"),
+	"flint_and_steel":        itemWorn("flint_and_steel"),
+	"apple":                  itemKinds("apple"),
+	"bow":                    itemWorn("bow"),
+	"arrow":                  itemKinds("arrow"),
+	"coal":                   itemKinds("coal", "charcoal"),
+	"diamond":                itemKinds("diamond"),
+	"iron_ingot":             itemKinds("iron_ingot"),
+	"gold_ingot":             itemKinds("gold_ingot"),
+	"iron_sword":             itemWorn("iron_sword"),
+	"wooden_sword":           itemWorn("wooden_sword"),
+	"wooden_shovel":          itemWorn("wooden_shovel"),
+	"wooden_pickaxe":         itemWorn("wooden_pickaxe"),
+	"wooden_axe":             itemWorn("wooden_axe"),
+	"stone_sword":            itemWorn("stone_sword"),
+	"stone_shovel":           itemWorn("stone_shovel"),
+	"stone_pickaxe":          itemWorn("stone_pickaxe"),
+	"stone_axe":              itemWorn("stone_axe"),
+	"diamond_sword":          itemWorn("diamond_sword"),
+	"diamond_shovel":         itemWorn("diamond_shovel"),
+	"diamond_pickaxe":        itemWorn("diamond_pickaxe"),
+	"diamond_axe":            itemWorn("diamond_axe"),
+	"stick":                  itemKinds("stick"),
+	"bowl":                   itemKinds("bowl"),
+	"mushroom_stew":          itemKinds("mushroom_stew"),
+	"golden_sword":           itemWorn("golden_sword"),
+	"golden_shovel":          itemWorn("golden_shovel"),
+	"golden_pickaxe":         itemWorn("golden_pickaxe"),
+	"golden_axe":             itemWorn("golden_axe"),
+	"string":                 itemKinds("string"),
+	"feather":                itemKinds("feather"),
+	"gunpowder":              itemKinds("gunpowder"),
+	"wooden_hoe":             itemWorn("wooden_hoe"),
+	"stone_hoe":              itemWorn("stone_hoe"),
+	"iron_hoe":               itemWorn("iron_hoe"),
+	"diamond_hoe":            itemWorn("diamond_hoe"),
+	"golden_hoe":             itemWorn("golden_hoe"),
+	"wheat_seeds":            itemKinds("wheat_seeds"),
+	"wheat":                  itemKinds("wheat"),
+	"bread":                  itemKinds("bread"),
+	"leather_helmet":         itemWorn("leather_helmet"),
+	"leather_chestplate":     itemWorn("leather_chestplate"),
+	"leather_leggings":       itemWorn("leather_leggings"),
+	"leather_boots":          itemWorn("leather_boots"),
+	"chainmail_helmet":       itemWorn("chainmail_helmet"),
+	"chainmail_chestplate":   itemWorn("chainmail_chestplate"),
+	"chainmail_leggings":     itemWorn("chainmail_leggings"),
+	"chainmail_boots":        itemWorn("chainmail_boots"),
+	"iron_helmet":            itemWorn("iron_helmet"),
+	"iron_chestplate":        itemWorn("iron_chestplate"),
+	"iron_leggings":          itemWorn("iron_leggings"),
+	"iron_boots":             itemWorn("iron_boots"),
+	"diamond_helmet":         itemWorn("diamond_helmet"),
+	"diamond_chestplate":     itemWorn("diamond_chestplate"),
+	"diamond_leggings":       itemWorn("diamond_leggings"),
+	"diamond_boots":          itemWorn("diamond_boots"),
+	"golden_helmet":          itemWorn("golden_helmet"),
+	"golden_chestplate":      itemWorn("golden_chestplate"),
+	"golden_leggings":        itemWorn("golden_leggings"),
+	"golden_boots":           itemWorn("golden_boots"),
+	"flint":                  itemKinds("flint"),
+	"porkchop":               itemKinds("porkchop"),
+	"cooked_porkchop":        itemKinds("cooked_porkchop"),
+	"painting":               itemKinds("painting"),
+	"golden_apple":           itemKinds("golden_apple", "enchanted_golden_apple"),
+	"sign":                   itemKinds("sign"),
+	"wooden_door":            itemKinds("oak_door"),
+	"bucket":                 itemKinds("bucket"),
+	"water_bucket":           itemKinds("water_bucket"),
+	"lava_bucket":            itemKinds("lava_bucket"),
+	"minecart":               itemKinds("minecart"),
+	"saddle":                 itemKinds("saddle"),
+	"iron_door":              itemKinds("iron_door"),
+	"redstone":               itemKinds("redstone"),
+	"snowball":               itemKinds("snowball"),
+	"boat":                   itemKinds("oak_boat"),
+	"leather":                itemKinds("leather"),
+	"milk_bucket":            itemKinds("milk_bucket"),
+	"brick":                  itemKinds("brick"),
+	"clay_ball":              itemKinds("clay_ball"),
+	"reeds":                  itemKinds("sugar_cane"),
+	"paper":                  itemKinds("paper"),
+	"book":                   itemKinds("book"),
+	"slime_ball":             itemKinds("slime_ball"),
+	"chest_minecart":         itemKinds("chest_minecart"),
+	"furnace_minecart":       itemKinds("furnace_minecart"),
+	"egg":                    itemKinds("egg"),
+	"compass":                itemKinds("compass"),
+	"fishing_rod":            itemWorn("fishing_rod"),
+	"clock":                  itemKinds("clock"),
+	"glowstone_dust":         itemKinds("glowstone_dust"),
+	"fish":                   itemKinds("cod", "salmon", "tropical_fish", "pufferfish"),
+	"cooked_fish":            itemKinds("cooked_cod", "cooked_salmon"),
+	"dye":                    itemKinds("ink_sac", "rose_red", "cactus_green", "cocoa_beans", "lapis_lazuli", "purple_dye", "cyan_dye", "light_gray_dye", "gray_dye", "pink_dye", "lime_dye", "dandelion_yellow", "light_blue_dye", "magenta_dye", "orange_dye", "bone_meal"),
+	"bone":                   itemKinds("bone"),
+	"sugar":                  itemKinds("sugar"),
+	"cake":                   itemKinds("cake"),
+	"bed":                    itemColored("bed", false),
+	"repeater":               itemKinds("repeater"),
+	"cookie":                 itemKinds("cookie"),
+	"filled_map":             itemKinds("filled_map"),
+	"shears":                 itemWorn("shears"),
+	"melon":                  itemKinds("melon_slice"),
+	"pumpkin_seeds":          itemKinds("pumpkin_seeds"),
+	"melon_seeds":            itemKinds("melon_seeds"),
+	"beef":                   itemKinds("beef"),
+	"cooked_beef":            itemKinds("cooked_beef"),
+	"chicken":                itemKinds("chicken"),
+	"cooked_chicken":         itemKinds("cooked_chicken"),
+	"rotten_flesh":           itemKinds("rotten_flesh"),
+	"ender_pearl":            itemKinds("ender_pearl"),
+	"blaze_rod":              itemKinds("blaze_rod"),
+	"ghast_tear":             itemKinds("ghast_tear"),
+	"gold_nugget":            itemKinds("gold_nugget"),
+	"nether_wart":            itemKinds("nether_wart"),
+	"potion":                 itemKinds("potion"),
+	"glass_bottle":           itemKinds("glass_bottle"),
+	"spider_eye":             itemKinds("spider_eye"),
+	"fermented_spider_eye":   itemKinds("fermented_spider_eye"),
+	"blaze_powder":           itemKinds("blaze_powder"),
+	"magma_cream":            itemKinds("magma_cream"),
+	"brewing_stand":          itemKinds("brewing_stand"),
+	"cauldron":               itemKinds("cauldron"),
+	"ender_eye":              itemKinds("ender_eye"),
+	"speckled_melon":         itemKinds("glistering_melon_slice"),
+	"experience_bottle":      itemKinds("experience_bottle"),
+	"fire_charge":            itemKinds("fire_charge"),
+	"writable_book":          itemKinds("writable_book"),
+	"written_book":           itemKinds("written_book"),
+	"emerald":                itemKinds("emerald"),
+	"item_frame":             itemKinds("item_frame"),
+	"flower_pot":             itemKinds("flower_pot"),
+	"carrot":                 itemKinds("carrot"),
+	"potato":                 itemKinds("potato"),
+	"baked_potato":           itemKinds("baked_potato"),
+	"poisonous_potato":       itemKinds("poisonous_potato"),
+	"map":                    itemKinds("map"),
+	"golden_carrot":          itemKinds("golden_carrot"),
+	"skull":                  itemKinds("skeleton_skull", "wither_skeleton_skull", "zombie_head", "player_head", "creeper_head", "dragon_head"),
+	"carrot_on_a_stick":      itemWorn("carrot_on_a_stick"),
+	"nether_star":            itemKinds("nether_star"),
+	"pumpkin_pie":            itemKinds("pumpkin_pie"),
+	"fireworks":              itemKinds("firework_rocket"),
+	"firework_charge":        itemKinds("firework_star"),
+	"enchanted_book":         itemKinds("enchanted_book"),
+	"comparator":             itemKinds("comparator"),
+	"netherbrick":            itemKinds("nether_brick"),
+	"quartz":                 itemKinds("quartz"),
+	"tnt_minecart":           itemKinds("tnt_minecart"),
+	"hopper_minecart":        itemKinds("hopper_minecart"),
+	"prismarine_shard":       itemKinds("prismarine_shard"),
+	"prismarine_crystals":    itemKinds("prismarine_crystals"),
+	"rabbit":                 itemKinds("rabbit"),
+	"cooked_rabbit":          itemKinds("cooked_rabbit"),
+	"rabbit_stew":            itemKinds("rabbit_stew"),
+	"rabbit_foot":            itemKinds("rabbit_foot"),
+	"rabbit_hide":            itemKinds("rabbit_hide"),
+	"armor_stand":            itemKinds("armor_stand"),
+	"iron_horse_armor":       itemKinds("iron_horse_armor"),
+	"golden_horse_armor":     itemKinds("golden_horse_armor"),
+	"diamond_horse_armor":    itemKinds("diamond_horse_armor"),
+	"lead":                   itemKinds("lead"),
+	"name_tag":               itemKinds("name_tag"),
+	"command_block_minecart": itemKinds("command_block_minecart"),
+	"mutton":                 itemKinds("mutton"),
+	"cooked_mutton":          itemKinds("cooked_mutton"),
+	"banner":                 itemColored("banner", true),
+	"end_crystal":            itemKinds("end_crystal"),
+	"spruce_door":            itemKinds("spruce_door"),
+	"birch_door":             itemKinds("birch_door"),
+	"jungle_door":            itemKinds("jungle_door"),
+	"acacia_door":            itemKinds("acacia_door"),
+	"dark_oak_door":          itemKinds("dark_oak_door"),
+	"chorus_fruit":           itemKinds("chorus_fruit"),
+	"chorus_fruit_popped":    itemKinds("popped_chorus_fruit"),
+	"beetroot":               itemKinds("beetroot"),
+	"beetroot_seeds":         itemKinds("beetroot_seeds"),
+	"beetroot_soup":          itemKinds("beetroot_soup"),
+	"dragon_breath":          itemKinds("dragon_breath"),
+	"splash_potion":          itemKinds("splash_potion"),
+	"spectral_arrow":         itemKinds("spectral_arrow"),
+	"tipped_arrow":           itemKinds("tipped_arrow"),
+	"lingering_potion":       itemKinds("lingering_potion"),
+	"shield":                 itemWorn("shield"),
+	"elytra":                 itemWorn("elytra"),
+	"spruce_boat":            itemKinds("spruce_boat"),
+	"birch_boat":             itemKinds("birch_boat"),
+	"jungle_boat":            itemKinds("jungle_boat"),
+	"acacia_boat":            itemKinds("acacia_boat"),
+	"dark_oak_boat":          itemKinds("dark_oak_boat"),
+	"totem_of_undying":       itemKinds("totem_of_undying"),
+	"shulker_shell":          itemKinds("shulker_shell"),
+	"iron_nugget":            itemKinds("iron_nugget"),
+	"knowledge_book":         itemKinds("knowledge_book"),
+	"record_13":              itemKinds("music_disc_13"),
+	"record_cat":             itemKinds("music_disc_cat"),
+	"record_blocks":          itemKinds("music_disc_blocks"),
+	"record_chirp":           itemKinds("music_disc_chirp"),
+	"record_far":             itemKinds("music_disc_far"),
+	"record_mall":            itemKinds("music_disc_mall"),
+	"record_mellohi":         itemKinds("music_disc_mellohi"),
+	"record_stal":            itemKinds("music_disc_stal"),
+	"record_strad":           itemKinds("music_disc_strad"),
+	"record_ward":            itemKinds("music_disc_ward"),
+	"record_11":              itemKinds("music_disc_11"),
+	"record_wait":            itemKinds("music_disc_wait"),
+}
+
+// the blocks that are also items, by the names they had before 1.13;  blocks that only the game itself places, such as
+// fire or the upper half of a door, have none
+//
+var legacyBlockItems = map[string]uint16{
+	"stone": 1, "grass": 2, "dirt": 3, "cobblestone": 4, "planks": 5, "sapling": 6, "bedrock": 7, "sand": 12, "gravel": 13,
+	"gold_ore": 14, "iron_ore": 15, "coal_ore": 16, "log": 17, "leaves": 18, "sponge": 19, "glass": 20, "lapis_ore": 21,
+	"lapis_block": 22, "dispenser": 23, "sandstone": 24, "noteblock": 25, "golden_rail": 27, "detector_rail": 28,
+	"sticky_piston": 29, "web": 30, "tallgrass": 31, "deadbush": 32, "piston": 33, "wool": 35, "yellow_flower": 37,
+	"red_flower": 38, "brown_mushroom": 39, "red_mushroom": 40, "gold_block": 41, "iron_block": 42, "stone_slab": 44,
+	"brick_block": 45, "tnt": 46, "bookshelf": 47, "mossy_cobblestone": 48, "obsidian": 49, "mob_spawner": 52,
+	"oak_stairs": 53, "diamond_ore": 56, "diamond_block": 57, "crafting_table": 58, "farmland": 60, "rail": 66,
+	"stone_stairs": 67, "lever": 69, "stone_pressure_plate": 70, "wooden_pressure_plate": 72, "redstone_ore": 73,
+	"stone_button": 77, "snow_layer": 78, "ice": 79, "snow": 80, "cactus": 81, "clay": 82, "jukebox": 84, "fence": 85,
+	"pumpkin": 86, "netherrack": 87, "soul_sand": 88, "glowstone": 89, "lit_pumpkin": 91, "stained_glass": 95,
+	"trapdoor": 96, "monster_egg": 97, "stonebrick": 98, "brown_mushroom_block": 99, "red_mushroom_block": 100,
+	"iron_bars": 101, "glass_pane": 102, "melon_block": 103, "vine": 106, "fence_gate": 107, "brick_stairs": 108,
+	"stone_brick_stairs": 109, "mycelium": 110, "waterlily": 111, "nether_brick": 112, "nether_brick_fence": 113,
+	"nether_brick_stairs": 114, "enchanting_table": 116, "end_portal_frame": 120, "end_stone": 121, "dragon_egg": 122,
+	"redstone_lamp": 123, "wooden_slab": 126, "sandstone_stairs": 128, "emerald_ore": 129, "tripwire_hook": 131,
+	"emerald_block": 133, "spruce_stairs": 134, "birch_stairs": 135, "jungle_stairs": 136, "command_block": 137,
+	"beacon": 138, "cobblestone_wall": 139, "wooden_button": 143, "light_weighted_pressure_plate": 147,
+	"heavy_weighted_pressure_plate": 148, "daylight_detector": 151, "redstone_block": 152, "quartz_ore": 153,
+	"hopper": 154, "quartz_block": 155, "quartz_stairs": 156, "activator_rail": 157, "dropper": 158,
+	"stained_hardened_clay": 159, "stained_glass_pane": 160, "leaves2": 161, "log2": 162, "acacia_stairs": 163,
+	"dark_oak_stairs": 164, "slime": 165, "barrier": 166, "iron_trapdoor": 167, "prismarine": 168, "sea_lantern": 169,
+	"hay_block": 170, "carpet": 171, "hardened_clay": 172, "coal_block": 173, "packed_ice": 174, "double_plant": 175,
+	"red_sandstone": 179, "red_sandstone_stairs": 180, "stone_slab2": 182, "spruce_fence_gate": 183,
+	"birch_fence_gate": 184, "jungle_fence_gate": 185, "dark_oak_fence_gate": 186, "acacia_fence_gate": 187,
+	"spruce_fence": 188, "birch_fence": 189, "jungle_fence": 190, "dark_oak_fence": 191, "acacia_fence": 192,
+	"end_rod": 198, "chorus_plant": 199, "chorus_flower": 200, "purpur_block": 201, "purpur_pillar": 202,
+	"purpur_stairs": 203, "purpur_slab": 205, "end_bricks": 206, "grass_path": 208, "repeating_command_block": 210,
+	"chain_command_block": 211, "magma": 213, "nether_wart_block": 214, "red_nether_brick": 215, "bone_block": 216,
+	"structure_void": 217, "observer": 218, "white_shulker_box": 219, "orange_shulker_box": 220,
+	"magenta_shulker_box": 221, "light_blue_shulker_box": 222, "yellow_shulker_box": 223, "lime_shulker_box": 224,
+	"pink_shulker_box": 225, "gray_shulker_box": 226, "silver_shulker_box": 227, "cyan_shulker_box": 228,
+	"purple_shulker_box": 229, "blue_shulker_box": 230, "brown_shulker_box": 231, "green_shulker_box": 232,
+	"red_shulker_box": 233, "black_shulker_box": 234, "white_glazed_terracotta": 235, "orange_glazed_terracotta": 236,
+	"magenta_glazed_terracotta": 237, "light_blue_glazed_terracotta": 238, "yellow_glazed_terracotta": 239,
+	"lime_glazed_terracotta": 240, "pink_glazed_terracotta": 241, "gray_glazed_terracotta": 242,
+	"silver_glazed_terracotta": 243, "cyan_glazed_terracotta": 244, "purple_glazed_terracotta": 245,
+	"blue_glazed_terracotta": 246, "brown_glazed_terracotta": 247, "green_glazed_terracotta": 248,
+	"red_glazed_terracotta": 249, "black_glazed_terracotta": 250, "concrete": 251, "concrete_powder": 252,
+	"structure_block": 255,
+}
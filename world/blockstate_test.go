@@ -0,0 +1,89 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"testing"
+)
+
+// the blocks that take up two blocks, one above the other, are each described by halves that say different things about
+// the block as a whole (see door and pairHalves)
+//
+func TestLegacyBlockStateHalves(t *testing.T) {
+	tests := []struct {
+		id    uint16
+		data  uint8
+		state string
+	}{
+		{64, 0, "minecraft:oak_door[facing=east,half=lower,hinge=left,open=false,powered=false]"},
+		{193, 3, "minecraft:spruce_door[facing=north,half=lower,hinge=left,open=false,powered=false]"},
+		{194, 5, "minecraft:birch_door[facing=south,half=lower,hinge=left,open=true,powered=false]"},
+		{195, 8, "minecraft:jungle_door[facing=east,half=upper,hinge=left,open=false,powered=false]"},
+		{196, 9, "minecraft:acacia_door[facing=east,half=upper,hinge=right,open=false,powered=false]"},
+		{197, 10, "minecraft:dark_oak_door[facing=east,half=upper,hinge=left,open=false,powered=true]"},
+		{71, 11, "minecraft:iron_door[facing=east,half=upper,hinge=right,open=false,powered=true]"},
+		{175, 0, "minecraft:sunflower[half=lower]"},
+		{175, 1, "minecraft:lilac[half=lower]"},
+		{175, 2, "minecraft:tall_grass[half=lower]"},
+		{175, 3, "minecraft:large_fern[half=lower]"},
+		{175, 4, "minecraft:rose_bush[half=lower]"},
+		{175, 5, "minecraft:peony[half=lower]"},
+		{175, 8, "minecraft:sunflower[half=upper]"},
+		{175, 10, "minecraft:sunflower[half=upper]"},
+		{175, 15, "minecraft:sunflower[half=upper]"},
+	}
+
+	for _, tt := range tests {
+		if s := LegacyBlockState(tt.id, tt.data, 1631); s.String() != tt.state {
+			t.Errorf("LegacyBlockState(%d, %d) = %s, want %s", tt.id, tt.data, s, tt.state)
+		}
+	}
+}
+
+// every data value that a door or double plant used goes back to the same id and data it came from; so, too, does either
+// half once it has been paired with the other
+//
+func TestBlockStateLegacyHalves(t *testing.T) {
+	for _, id := range []uint16{64, 71, 193, 194, 195, 196, 197} {
+		for data := uint8(0); data < 12; data++ {
+			s := LegacyBlockState(id, data, 1631)
+			gotID, gotData, err := BlockStateLegacy(s, 1631)
+			if err != nil || gotID != id || gotData != data {
+				t.Errorf("BlockStateLegacy(%s) = %d, %d, %v, want %d, %d", s, gotID, gotData, err, id, data)
+			}
+		}
+	}
+
+	for data := uint8(0); data < 6; data++ {
+		s := LegacyBlockState(175, data, 1631)
+		gotID, gotData, err := BlockStateLegacy(s, 1631)
+		if err != nil || gotID != 175 || gotData != data {
+			t.Errorf("BlockStateLegacy(%s) = %d, %d, %v, want 175, %d", s, gotID, gotData, err, data)
+		}
+	}
+
+	tests := []struct {
+		state string
+		id    uint16
+		data  uint8
+	}{
+		{"minecraft:spruce_door[facing=north,half=upper,hinge=right,open=false,powered=false]", 193, 9},
+		{"minecraft:spruce_door[facing=north,half=upper,hinge=left,open=true,powered=true]", 193, 10},
+		{"minecraft:spruce_door[facing=north,half=lower,hinge=right,open=false,powered=false]", 193, 3},
+		{"minecraft:lilac[half=upper]", 175, 8},
+		{"minecraft:peony[half=upper]", 175, 8},
+		{"minecraft:large_fern[half=upper]", 175, 8},
+	}
+
+	for _, tt := range tests {
+		s, err := ParseBlockState(tt.state)
+		if err != nil {
+			t.Fatalf("ParseBlockState(%s) : %s", tt.state, err)
+		}
+		gotID, gotData, err := BlockStateLegacy(s, 1631)
+		if err != nil || gotID != tt.id || gotData != tt.data {
+			t.Errorf("BlockStateLegacy(%s) = %d, %d, %v, want %d, %d", tt.state, gotID, gotData, err, tt.id, tt.data)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+
+	"github.com/landru27/nbt"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// items
+//
+// an item, in a chest's Items list or a player's Inventory, is a compound of its id, Slot, Count and, before 1.13, Damage,
+// along with a tag compound of anything else about it;  blueprints describe items the way they were before 1.13, and
+// Minecraft only converts items as it converts the chunk or player file they are in, which it does not do for one already
+// written by a later version, so an item written into a world of 1.13 or later has to be converted here (see LegacyItem) :
+//
+//     id      : the item id since 1.13, e.g., 'minecraft:wool' with a Damage of 8 is 'minecraft:light_gray_wool'
+//     Damage  : dropped, or, for a tool, weapon or piece of armour, kept as the Int tag.Damage
+//     ench    : the enchantments of the item, as tag.Enchantments, with each one named rather than numbered;  so, too, the
+//               tag.StoredEnchantments of an enchanted book
+//
+
+// the enchantments, by their numbers before 1.13
+//
+var legacyEnchantments = map[int16]string{
+	0:  "protection",
+	1:  "fire_protection",
+	2:  "feather_falling",
+	3:  "blast_protection",
+	4:  "projectile_protection",
+	5:  "respiration",
+	6:  "aqua_affinity",
+	7:  "thorns",
+	8:  "depth_strider",
+	9:  "frost_walker",
+	10: "binding_curse",
+	16: "sharpness",
+	17: "smite",
+	18: "bane_of_arthropods",
+	19: "knockback",
+	20: "fire_aspect",
+	21: "looting",
+	22: "sweeping",
+	32: "efficiency",
+	33: "silk_touch",
+	34: "unbreaking",
+	35: "fortune",
+	48: "power",
+	49: "punch",
+	50: "flame",
+	51: "infinity",
+	61: "luck_of_the_sea",
+	62: "lure",
+	70: "mending",
+	71: "vanishing_curse",
+}
+
+// FlattenItem converts an item compound, as it was before 1.13, into the item Minecraft would convert it into, for the
+// given DataVersion;  an item with no equivalent since is an error
+//
+func FlattenItem(item *nbt.NBT, dataVersion int) (err error) {
+	nbtID := NBTChild(item, "id")
+	if nbtID == nil {
+		return fmt.Errorf("an item with no id")
+	}
+	name, _ := nbtID.Data.(string)
+
+	var damage int16
+	if nbtDamage := NBTChild(item, "Damage"); nbtDamage != nil {
+		damage, _ = nbtDamage.Data.(int16)
+	}
+
+	id, worn, err := LegacyItem(name, damage, dataVersion)
+	if err != nil {
+		return fmt.Errorf("item [%s] with Damage %d [%s]", name, damage, err)
+	}
+
+	nbtID.Data = id
+	nbtID.Size = uint32(len(id))
+	removeNBTChild(item, "Damage")
+
+	tag := NBTChild(item, "tag")
+	if worn && damage != 0 {
+		if tag == nil {
+			item.Data = append(item.Data.([]nbt.NBT), nbt.NBT{nbt.TAG_Compound, 0, "tag", 0, make([]nbt.NBT, 0)})
+			item.Size = uint32(len(item.Data.([]nbt.NBT)))
+			tag = NBTChild(item, "tag")
+		}
+		removeNBTChild(tag, "Damage")
+		tag.Data = append(tag.Data.([]nbt.NBT), nbt.NBT{nbt.TAG_Int, 0, "Damage", 0, int32(damage)})
+		tag.Size = uint32(len(tag.Data.([]nbt.NBT)))
+	}
+
+	if nbtEnch := NBTChild(tag, "ench"); nbtEnch != nil {
+		nbtEnch.Name = "Enchantments"
+		err = flattenEnchantments(nbtEnch)
+		if err != nil {
+			return
+		}
+	}
+	if nbtStored := NBTChild(tag, "StoredEnchantments"); nbtStored != nil {
+		err = flattenEnchantments(nbtStored)
+	}
+
+	return
+}
+
+// an enchantment is a compound of its id and lvl; the id was a Short, and is since a String
+//
+func flattenEnchantments(list *nbt.NBT) (err error) {
+	enchantments, okay := list.Data.([]nbt.NBT)
+	if !okay {
+		return
+	}
+
+	for indx := range enchantments {
+		nbtID := NBTChild(&enchantments[indx], "id")
+		if nbtID == nil || nbtID.Type != nbt.TAG_Short {
+			continue
+		}
+
+		number, _ := nbtID.Data.(int16)
+		name, okay := legacyEnchantments[number]
+		if !okay {
+			return fmt.Errorf("unknown enchantment %d", number)
+		}
+
+		nbtID.Type = nbt.TAG_String
+		nbtID.Data = "minecraft:" + name
+		nbtID.Size = uint32(len(nbtID.Data.(string)))
+	}
+
+	return
+}
+
+func removeNBTChild(n *nbt.NBT, name string) {
+	if n == nil || n.Type != nbt.TAG_Compound {
+		return
+	}
+
+	var kept []nbt.NBT
+	for _, elem := range n.Data.([]nbt.NBT) {
+		if elem.Name != name {
+			kept = append(kept, elem)
+		}
+	}
+	if kept == nil {
+		kept = make([]nbt.NBT, 0)
+	}
+
+	n.Data = kept
+	n.Size = uint32(len(kept))
+}
@@ -0,0 +1,326 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"time"
+
+	"github.com/landru27/nbt"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// MCJournal
+//
+// a journal records what was in the world before each edit, so that a blueprint application can be undone; the Edit*
+// functions add a record just before they change anything, and undoing is a matter of replaying the records in reverse
+// order, each one putting back what it found
+//
+// record kinds :
+//     section       : a Section we added to a chunk (X, Y, Z are the Section's lowest, westernmost, northernmost block)
+//     block         : the block id and data at X, Y, Z; or, for a chunk stored in the palette format (see section.go),
+//                     the block-state at X, Y, Z
+//     heightmap     : the HeightMap value for the column at X, Z (kept by earlier versions, which patched up the HeightMap
+//                     after a render, rather than working it out afresh; see recomputeHeightMaps)
+//     heightmaps    : all of the Heightmaps of the chunk containing X, Z, before they were left to Minecraft to redo
+//     blockentities : the blockentities at X, Y, Z (usually none, unless the blueprint is being redone)
+//     chunkentities : all of the blockentities of the chunk containing X, Z, before a reset (see -resetblockentities)
+//     entity        : the UUID of an entity we added to the chunk containing X, Z (as two Longs, in whichever form the
+//                     entity has it; see entityUUID)
+//     biome         : the biomes of the column at X, Z, from the bottom up (see biome.go)
+//
+// blockentities and Heightmaps are stored as binary NBT, so that they come back exactly as they were;  a chunk's HeightMap
+// and light are not journaled, since undoing a block is itself an edit, and they are worked out afresh for it as the chunk
+// is saved, just as they were after the render being undone
+//
+type MCJournal struct {
+	PathWorld string            `json:"pathworld"`
+	Time      string            `json:"time"`
+	Filename  string            `json:"-"`
+	Records   []MCJournalRecord `json:"records"`
+}
+
+type MCJournalRecord struct {
+	Kind           string   `json:"kind"`
+	X              int      `json:"x"`
+	Y              int      `json:"y"`
+	Z              int      `json:"z"`
+	ID             uint16   `json:"id,omitempty"`
+	Data           uint8    `json:"data,omitempty"`
+	State          string   `json:"state,omitempty"`
+	HeightMap      int32    `json:"heightmap,omitempty"`
+	BlockEntities  [][]byte `json:"blockentities,omitempty"`
+	Heightmaps     []byte   `json:"heightmaps,omitempty"`
+	Biomes         []byte   `json:"biomes,omitempty"`
+	UUIDMost       int64    `json:"uuidmost,omitempty"`
+	UUIDLeast      int64    `json:"uuidleast,omitempty"`
+}
+
+// the journal lives next to the region directory, rather than in it, so as not to clutter the region files; the
+// timestamp (of the run making the edits) keeps each run's journal separate
+//
+func NewMCJournal(pathWorld string, timeExec time.Time) (j *MCJournal) {
+	j = &MCJournal{PathWorld: pathWorld, Time: timeExec.Format("2006-01-02T15:04:05Z07:00")}
+	j.Filename = filepath.Join(filepath.Dir(filepath.Clean(pathWorld)), fmt.Sprintf("worldcraft-journal.%s.json", timeExec.Format("20060102-150405.000000")))
+	j.Records = make([]MCJournalRecord, 0)
+
+	return
+}
+
+func ReadMCJournal(filename string) (j *MCJournal, err error) {
+	var bufJson []byte
+
+	bufJson, err = ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	j = &MCJournal{Filename: filename}
+	err = json.Unmarshal(bufJson, j)
+
+	return
+}
+
+func (j *MCJournal) Write() (err error) {
+	var bufJson []byte
+
+	bufJson, err = json.Marshal(j)
+	if err != nil {
+		return
+	}
+
+	err = ioutil.WriteFile(j.Filename, bufJson, 0644)
+
+	return
+}
+
+func (j *MCJournal) Record(rec MCJournalRecord) {
+	j.Records = append(j.Records, rec)
+}
+
+// these are called by the Edit* functions, to record the current state of things before they change it
+//
+func (w *MCWorld) journalBlock(x int, y int, z int, chnk *MCChunk, sect sectionCodec, indxBlock int) (err error) {
+	rec := MCJournalRecord{Kind: "block", X: x, Y: y, Z: z}
+
+	// a block-state is kept as it is, since it might not translate back and forth exactly
+	if chnk.DataVersion >= dataVersionFlattening {
+		rec.State = sect.getState(indxBlock).String()
+	} else {
+		rec.ID, rec.Data, err = sect.getBlock(indxBlock)
+		if err != nil {
+			return
+		}
+	}
+
+	w.Journal.Record(rec)
+
+	return
+}
+
+func (w *MCWorld) journalHeightmaps(x int, z int, dataHeightmaps *nbt.NBT) (err error) {
+	var bufNBT bytes.Buffer
+	err = nbt.WriteNBTData(&bufNBT, dataHeightmaps)
+	if err != nil {
+		return
+	}
+
+	w.Journal.Record(MCJournalRecord{Kind: "heightmaps", X: x, Z: z, Heightmaps: bufNBT.Bytes()})
+
+	return
+}
+
+func (w *MCWorld) journalBlockEntities(kind string, x int, y int, z int, dataBlockEntities *nbt.NBT) (err error) {
+	rec := MCJournalRecord{Kind: kind, X: x, Y: y, Z: z}
+	rec.BlockEntities = make([][]byte, 0)
+
+	for indx := range dataBlockEntities.Data.([]nbt.NBT) {
+		elem := &dataBlockEntities.Data.([]nbt.NBT)[indx]
+
+		if kind == "blockentities" && !blockEntityIsAt(elem, x, y, z) {
+			continue
+		}
+
+		var bufNBT bytes.Buffer
+		err = nbt.WriteNBTData(&bufNBT, elem)
+		if err != nil {
+			return
+		}
+
+		rec.BlockEntities = append(rec.BlockEntities, bufNBT.Bytes())
+	}
+
+	w.Journal.Record(rec)
+
+	return
+}
+
+func blockEntityIsAt(elem *nbt.NBT, x int, y int, z int) bool {
+	bx := NBTChild(elem, "x")
+	by := NBTChild(elem, "y")
+	bz := NBTChild(elem, "z")
+	if bx == nil || by == nil || bz == nil {
+		return false
+	}
+
+	vx, okayX := bx.Data.(int32)
+	vy, okayY := by.Data.(int32)
+	vz, okayZ := bz.Data.(int32)
+	if !okayX || !okayY || !okayZ {
+		return false
+	}
+
+	return vx == int32(x) && vy == int32(y) && vz == int32(z)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// undo : replay a journal, last record first, putting back what each record found
+//
+// the flags that make Edit* skip things are turned off, and no new journal is kept, so that every record is replayed
+// exactly as it was recorded
+//
+func (w *MCWorld) UndoJournal(j *MCJournal) (err error) {
+	w.FlagXAirBlocks = false
+	w.FlagSkipEntities = false
+	w.FlagSkipBlockEntities = false
+	w.FlagResetBlockEntities = false
+	w.Journal = nil
+
+	for indx := len(j.Records) - 1; indx >= 0; indx-- {
+		rec := j.Records[indx]
+
+		var rgn *MCRegion
+		rgn, err = w.LoadRegion(rec.X, rec.Z)
+		if err != nil {
+			return
+		}
+		rgn.Dirty = true
+
+		cx := int(math.Floor(float64(rec.X) / 16.0))
+		cz := int(math.Floor(float64(rec.Z) / 16.0))
+		chnk := &rgn.Chunks[((cz - (rgn.RZ * 32)) * 32) + (cx - (rgn.RX * 32))]
+		err = w.DecodeChunk(chnk)
+		if err != nil {
+			return
+		}
+		indxHeightMap := ((rec.Z - (cz * 16)) * 16) + (rec.X - (cx * 16))
+
+		switch rec.Kind {
+		case "section":
+			// we only ever add Sections at the end of the list, so the last one should be ours, to take back off
+			err = chnk.removeSection(rec.Y / 16)
+			if err != nil {
+				return fmt.Errorf("undo : chunk %d, %d : %s", cx, cz, err)
+			}
+
+		case "block":
+			if rec.State != "" {
+				var s MCBlockState
+				s, err = ParseBlockState(rec.State)
+				if err != nil {
+					return
+				}
+				err = w.EditBlockState(rec.X, rec.Y, rec.Z, s)
+			} else {
+				err = w.EditBlock(rec.X, rec.Y, rec.Z, rec.ID, rec.Data)
+			}
+			if err != nil {
+				return
+			}
+
+		case "heightmap":
+			// the HeightMap of every edited column is worked out afresh as it is saved, so this is only a matter of
+			// making sure that this column is among them
+			chnk.EditColumns[indxHeightMap] = true
+
+		case "heightmaps":
+			var elem nbt.NBT
+			elem, err = nbt.ReadNBTData(bytes.NewReader(rec.Heightmaps), nbt.TAG_NULL, "")
+			if err != nil {
+				return
+			}
+			dataHeightmaps := chnk.ChunkDataRefs["Heightmaps"]
+			if dataHeightmaps == nil {
+				return fmt.Errorf("undo : chunk %d, %d has no Heightmaps to put back", cx, cz)
+			}
+			*dataHeightmaps = elem
+
+		case "blockentities", "chunkentities":
+			dataBlockEntities := chnk.ChunkDataRefs["TileEntities"]
+			if dataBlockEntities == nil {
+				return fmt.Errorf("undo : chunk %d, %d has no TileEntities to put blockentities back into", cx, cz)
+			}
+
+			keep := make([]nbt.NBT, 0)
+			if rec.Kind == "blockentities" {
+				for _, elem := range dataBlockEntities.Data.([]nbt.NBT) {
+					if !blockEntityIsAt(&elem, rec.X, rec.Y, rec.Z) {
+						keep = append(keep, elem)
+					}
+				}
+			}
+
+			for _, bufNBT := range rec.BlockEntities {
+				var elem nbt.NBT
+				elem, err = nbt.ReadNBTData(bytes.NewReader(bufNBT), nbt.TAG_NULL, "")
+				if err != nil {
+					return
+				}
+				keep = append(keep, elem)
+			}
+
+			// Minecraft writes an empty list as a list of TAG_End; see EditBlockEntity
+			dataBlockEntities.List = nbt.TAG_Compound
+			if len(keep) == 0 {
+				dataBlockEntities.List = nbt.TAG_End
+			}
+			dataBlockEntities.Size = uint32(len(keep))
+			dataBlockEntities.Data = keep
+
+		case "entity":
+			dataEntities := chnk.ChunkDataRefs["Entities"]
+			if dataEntities == nil {
+				return fmt.Errorf("undo : chunk %d, %d has no Entities to take entity %d/%d out of", cx, cz, rec.UUIDMost, rec.UUIDLeast)
+			}
+
+			keep := make([]nbt.NBT, 0)
+			found := false
+			for _, elem := range dataEntities.Data.([]nbt.NBT) {
+				// the UUID is matched in whichever form the entity has it (see entityUUID)
+				most, least, okay := entityUUID(&elem)
+				if okay && most == rec.UUIDMost && least == rec.UUIDLeast {
+					found = true
+					continue
+				}
+				keep = append(keep, elem)
+			}
+
+			if !found {
+				fmt.Fprintf(w.Log, "undo : entity %d/%d is no longer in chunk %d, %d; it might have wandered off\n", rec.UUIDMost, rec.UUIDLeast, cx, cz)
+			}
+
+			if len(keep) == 0 {
+				dataEntities.List = nbt.TAG_End
+			}
+			dataEntities.Size = uint32(len(keep))
+			dataEntities.Data = keep
+
+		case "biome":
+			if dataBiomes := chnk.ChunkDataRefs["Biomes"]; dataBiomes != nil && len(rec.Biomes) > 0 {
+				// a column's biomes are indexed the same as its HeightMap
+				setBiomeColumn(dataBiomes, indxHeightMap, rec.Biomes)
+			}
+
+		default:
+			return fmt.Errorf("unknown journal record kind [%s]", rec.Kind)
+		}
+	}
+
+	return
+}
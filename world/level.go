@@ -0,0 +1,245 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/landru27/nbt"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// MCLevel
+//
+// a save folder holds a world's level.dat, which describes the world as a whole, alongside the region directories of each
+// of its dimensions :
+//
+//     level.dat    : GZip-compressed NBT, with everything of interest in its Data compound
+//     region/      : the Overworld
+//     DIM-1/region : the Nether
+//     DIM1/region  : the End
+//
+type MCLevel struct {
+	LevelName   string
+	Version     int
+	DataVersion int
+	VersionName string
+	SpawnX      int
+	SpawnY      int
+	SpawnZ      int
+	Time        int64
+	DayTime     int64
+	LastPlayed  int64
+	Format      MCFormatProfile
+	Data        nbt.NBT
+}
+
+// the region directory of each dimension, relative to the save folder
+//
+var dimensionDirs = map[string]string{
+	"overworld": "region",
+	"nether":    filepath.Join("DIM-1", "region"),
+	"end":       filepath.Join("DIM1", "region"),
+}
+
+// ReadMCLevel reads a level.dat;  only the Data compound is kept, since that is all there is in it;  worlds from before
+// 1.9 have no DataVersion, nor a Version compound to name the version of the game that last saved them
+//
+func ReadMCLevel(filename string) (l *MCLevel, err error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer fh.Close()
+
+	rdr, err := gzip.NewReader(fh)
+	if err != nil {
+		return nil, fmt.Errorf("unable to uncompress [%s] [%s]", filename, err)
+	}
+
+	root, err := nbt.ReadNBTData(rdr, nbt.TAG_NULL, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse [%s] [%s]", filename, err)
+	}
+
+	data := NBTChild(&root, "Data")
+	if data == nil {
+		return nil, fmt.Errorf("[%s] has no Data compound", filename)
+	}
+
+	l = &MCLevel{Data: *data}
+
+	if l.LevelName, err = levelString(filename, data, "LevelName"); err != nil {
+		return nil, err
+	}
+	if l.Version, err = levelInt(filename, data, "version"); err != nil {
+		return nil, err
+	}
+	if l.DataVersion, err = levelInt(filename, data, "DataVersion"); err != nil {
+		return nil, err
+	}
+	if l.VersionName, err = levelString(filename, NBTChild(data, "Version"), "Name"); err != nil {
+		return nil, err
+	}
+	if l.SpawnX, err = levelInt(filename, data, "SpawnX"); err != nil {
+		return nil, err
+	}
+	if l.SpawnY, err = levelInt(filename, data, "SpawnY"); err != nil {
+		return nil, err
+	}
+	if l.SpawnZ, err = levelInt(filename, data, "SpawnZ"); err != nil {
+		return nil, err
+	}
+	if l.Time, err = levelLong(filename, data, "Time"); err != nil {
+		return nil, err
+	}
+	if l.DayTime, err = levelLong(filename, data, "DayTime"); err != nil {
+		return nil, err
+	}
+	if l.LastPlayed, err = levelLong(filename, data, "LastPlayed"); err != nil {
+		return nil, err
+	}
+
+	// an unsupported format is left for OpenDimension to refuse, so that the level can still be looked at
+	l.Format, _ = FormatProfile(l.DataVersion)
+
+	return
+}
+
+// a level.dat is written by Minecraft, or by any of a number of other tools, so the type of each tag is checked as it is
+// read, rather than taken on trust;  a tag that is not there at all is left at its zero value
+//
+func levelInt(filename string, data *nbt.NBT, name string) (valu int, err error) {
+	nbtElem := NBTChild(data, name)
+	if nbtElem == nil {
+		return
+	}
+
+	i, okay := nbtElem.Data.(int32)
+	if !okay {
+		return 0, fmt.Errorf("[%s] has a %s tag holding %T, where an Int was expected", filename, name, nbtElem.Data)
+	}
+
+	return int(i), nil
+}
+
+func levelLong(filename string, data *nbt.NBT, name string) (valu int64, err error) {
+	nbtElem := NBTChild(data, name)
+	if nbtElem == nil {
+		return
+	}
+
+	valu, okay := nbtElem.Data.(int64)
+	if !okay {
+		return 0, fmt.Errorf("[%s] has a %s tag holding %T, where a Long was expected", filename, name, nbtElem.Data)
+	}
+
+	return
+}
+
+func levelString(filename string, data *nbt.NBT, name string) (valu string, err error) {
+	nbtElem := NBTChild(data, name)
+	if nbtElem == nil {
+		return
+	}
+
+	valu, okay := nbtElem.Data.(string)
+	if !okay {
+		return "", fmt.Errorf("[%s] has a %s tag holding %T, where a String was expected", filename, name, nbtElem.Data)
+	}
+
+	return
+}
+
+// OpenDimension readies a world, given either its save folder or one of its region directories;  from a save folder,
+// the region directory opened is that of the dimension named ('overworld', the default, 'nether', or 'end');  either way,
+// the world's level.dat is read, if there is one, into w.Level
+//
+func OpenDimension(path string, dimension string) (w *MCWorld, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		err = fmt.Errorf("unable to open world [%s] [%s]", path, err)
+		return
+	}
+	if !info.IsDir() {
+		err = fmt.Errorf("unable to open world [%s] : not a directory", path)
+		return
+	}
+
+	w = &MCWorld{
+		PathWorld: path,
+		PathSave:  saveFolderOf(path),
+		Dimension: dimensionOf(path),
+		Regions:   make(map[[2]int]*MCRegion),
+		CacheSize: 16,
+		Jobs:      runtime.NumCPU(),
+		Log:       ioutil.Discard,
+		Light:     make(map[uint16]MCBlockLight),
+	}
+
+	// a save folder is known by its level.dat
+	if _, errStat := os.Stat(filepath.Join(path, "level.dat")); errStat == nil {
+		if dimension == "" {
+			dimension = "overworld"
+		}
+
+		dir, okay := dimensionDirs[dimension]
+		if !okay {
+			return nil, fmt.Errorf("unknown dimension [%s]; expected 'overworld', 'nether' or 'end'", dimension)
+		}
+
+		w.PathSave = path
+		w.PathWorld = filepath.Join(path, dir)
+		w.Dimension = dimension
+		if info, err = os.Stat(w.PathWorld); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("unable to open world [%s] : it has no %s region directory [%s]", path, dimension, w.PathWorld)
+		}
+	} else if dimension != "" {
+		return nil, fmt.Errorf("unable to open world [%s] : a dimension can only be chosen from a save folder, with a level.dat", path)
+	}
+
+	if _, errStat := os.Stat(filepath.Join(w.PathSave, "level.dat")); errStat == nil {
+		w.Level, err = ReadMCLevel(filepath.Join(w.PathSave, "level.dat"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to open world [%s] [%s]", path, err)
+		}
+
+		if _, err = FormatProfile(w.Level.DataVersion); err != nil {
+			return nil, fmt.Errorf("unable to open world [%s] : it was last saved by Minecraft %s; %s", path, w.Level.VersionName, err)
+		}
+	}
+
+	return
+}
+
+// a region directory of a save folder's own is in DIM-1 for the Nether, and in DIM1 for the End
+//
+func dimensionOf(pathWorld string) (dimension string) {
+	dimension = "overworld"
+	switch filepath.Base(filepath.Dir(filepath.Clean(pathWorld))) {
+	case "DIM-1":
+		dimension = "nether"
+	case "DIM1":
+		dimension = "end"
+	}
+
+	return
+}
+
+// the save folder holds the region directory; for the Nether and the End, the region directory is one level further down,
+// in DIM-1 or DIM1
+//
+func saveFolderOf(pathWorld string) (pathSave string) {
+	pathSave = filepath.Dir(filepath.Clean(pathWorld))
+	if base := filepath.Base(pathSave); base == "DIM-1" || base == "DIM1" {
+		pathSave = filepath.Dir(pathSave)
+	}
+
+	return
+}
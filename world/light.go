@@ -0,0 +1,660 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"math"
+	"sort"
+
+	"github.com/landru27/nbt"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Lighting
+//
+// every block has two light levels, 0 to 15, kept in each Section's SkyLight and BlockLight nybble arrays :  sky light is
+// 15 in every block that the sky shines straight down on, and block light is whatever a block gives off itself (torches,
+// glowstone, lava, and so on);  from there, light spreads to each neighbouring block, one level dimmer per block, or more
+// than one, for blocks such as water and leaves that let light through only partly, and not at all into solid blocks
+//
+// Minecraft only works out light on its own for chunks whose LightPopulated is 0, and even then not always, and not always
+// right away;  so rather than leave that to chance, we work out the light of every chunk we edit, and of the chunks
+// around it, into which its light (or shadow) can reach, before saving them (see relight)
+//
+type MCBlockLight struct {
+	Opacity  uint8 `json:"opacity"`
+	Emission uint8 `json:"emission"`
+}
+
+// the blocks that are not simply solid, keyed by block id, the same as the glyph legend;  any block not listed here is
+// taken to be a solid block, which lets no light through and gives off none;  blocks not known here at all, such as
+// those of mods, can be described in the glyph legend (see MCWorld.Light)
+//
+var blockLights = map[uint16]MCBlockLight{
+	0:   {0, 0},    // air
+	6:   {0, 0},    // sapling
+	8:   {3, 0},    // flowing water
+	9:   {3, 0},    // water
+	10:  {0, 15},   // flowing lava
+	11:  {0, 15},   // lava
+	18:  {1, 0},    // leaves
+	20:  {0, 0},    // glass
+	26:  {0, 0},    // bed
+	27:  {0, 0},    // powered rail
+	28:  {0, 0},    // detector rail
+	30:  {1, 0},    // cobweb
+	31:  {0, 0},    // tall grass
+	32:  {0, 0},    // dead bush
+	34:  {0, 0},    // piston head
+	36:  {0, 0},    // moving piston
+	37:  {0, 0},    // dandelion
+	38:  {0, 0},    // flowers
+	39:  {0, 1},    // brown mushroom
+	40:  {0, 0},    // red mushroom
+	50:  {0, 14},   // torch
+	51:  {0, 15},   // fire
+	52:  {0, 0},    // mob spawner
+	54:  {0, 0},    // chest
+	55:  {0, 0},    // redstone wire
+	59:  {0, 0},    // wheat
+	62:  {15, 13},  // lit furnace
+	63:  {0, 0},    // standing sign
+	64:  {0, 0},    // oak door
+	65:  {0, 0},    // ladder
+	66:  {0, 0},    // rail
+	68:  {0, 0},    // wall sign
+	69:  {0, 0},    // lever
+	70:  {0, 0},    // stone pressure plate
+	71:  {0, 0},    // iron door
+	72:  {0, 0},    // wooden pressure plate
+	74:  {15, 9},   // lit redstone ore
+	75:  {0, 0},    // unlit redstone torch
+	76:  {0, 7},    // redstone torch
+	77:  {0, 0},    // stone button
+	78:  {0, 0},    // snow layer
+	79:  {3, 0},    // ice
+	81:  {0, 0},    // cactus
+	83:  {0, 0},    // sugar cane
+	85:  {0, 0},    // oak fence
+	89:  {15, 15},  // glowstone
+	90:  {0, 11},   // nether portal
+	91:  {15, 15},  // jack o'lantern
+	92:  {0, 0},    // cake
+	93:  {0, 0},    // repeater
+	94:  {0, 0},    // powered repeater
+	95:  {0, 0},    // stained glass
+	96:  {0, 0},    // trapdoor
+	101: {0, 0},    // iron bars
+	102: {0, 0},    // glass pane
+	104: {0, 0},    // pumpkin stem
+	105: {0, 0},    // melon stem
+	106: {0, 0},    // vines
+	107: {0, 0},    // oak fence gate
+	111: {0, 0},    // lily pad
+	113: {0, 0},    // nether brick fence
+	115: {0, 0},    // nether wart
+	116: {0, 0},    // enchanting table
+	117: {0, 1},    // brewing stand
+	118: {0, 0},    // cauldron
+	119: {0, 15},   // end portal
+	120: {0, 1},    // end portal frame
+	122: {0, 1},    // dragon egg
+	124: {15, 15},  // lit redstone lamp
+	127: {0, 0},    // cocoa
+	130: {0, 7},    // ender chest
+	131: {0, 0},    // tripwire hook
+	132: {0, 0},    // tripwire
+	138: {0, 15},   // beacon
+	139: {0, 0},    // cobblestone wall
+	140: {0, 0},    // flower pot
+	141: {0, 0},    // carrots
+	142: {0, 0},    // potatoes
+	143: {0, 0},    // wooden button
+	144: {0, 0},    // skull
+	145: {0, 0},    // anvil
+	146: {0, 0},    // trapped chest
+	147: {0, 0},    // light weighted pressure plate
+	148: {0, 0},    // heavy weighted pressure plate
+	149: {0, 0},    // comparator
+	150: {0, 9},    // powered comparator
+	151: {0, 0},    // daylight detector
+	154: {0, 0},    // hopper
+	157: {0, 0},    // activator rail
+	160: {0, 0},    // stained glass pane
+	161: {1, 0},    // leaves2
+	165: {0, 0},    // slime block
+	166: {0, 0},    // barrier
+	167: {0, 0},    // iron trapdoor
+	169: {15, 15},  // sea lantern
+	171: {0, 0},    // carpet
+	175: {0, 0},    // double plant
+	176: {0, 0},    // standing banner
+	177: {0, 0},    // wall banner
+	178: {0, 0},    // inverted daylight detector
+	183: {0, 0},    // spruce fence gate
+	184: {0, 0},    // birch fence gate
+	185: {0, 0},    // jungle fence gate
+	186: {0, 0},    // dark oak fence gate
+	187: {0, 0},    // acacia fence gate
+	188: {0, 0},    // spruce fence
+	189: {0, 0},    // birch fence
+	190: {0, 0},    // jungle fence
+	191: {0, 0},    // dark oak fence
+	192: {0, 0},    // acacia fence
+	193: {0, 0},    // spruce door
+	194: {0, 0},    // birch door
+	195: {0, 0},    // jungle door
+	196: {0, 0},    // acacia door
+	197: {0, 0},    // dark oak door
+	198: {0, 14},   // end rod
+	199: {0, 0},    // chorus plant
+	200: {0, 0},    // chorus flower
+	207: {0, 0},    // beetroots
+	209: {0, 15},   // end gateway
+	212: {3, 0},    // frosted ice
+	213: {15, 3},   // magma
+	217: {0, 0},    // structure void
+	219: {0, 0},    // shulker boxes, 219 to 234
+	220: {0, 0},
+	221: {0, 0},
+	222: {0, 0},
+	223: {0, 0},
+	224: {0, 0},
+	225: {0, 0},
+	226: {0, 0},
+	227: {0, 0},
+	228: {0, 0},
+	229: {0, 0},
+	230: {0, 0},
+	231: {0, 0},
+	232: {0, 0},
+	233: {0, 0},
+	234: {0, 0},
+}
+
+var solidBlockLight = MCBlockLight{15, 0}
+
+func (w *MCWorld) blockLight(id uint16) (l MCBlockLight) {
+	if l, okay := w.Light[id]; okay {
+		return l
+	}
+	if l, okay := blockLights[id]; okay {
+		return l
+	}
+
+	return solidBlockLight
+}
+
+// a Section in the palette format is looked up by block-state, once for each entry of its Palette, rather than once for
+// each block;  block-states with no legacy id at all (i.e., blocks from since 1.12) are taken to be solid, unless they are
+// one of the kinds of air
+//
+func (w *MCWorld) sectionLights(sect sectionCodec, opacity []uint8, emission []uint8) {
+	if ps, okay := sect.(*paletteSection); okay {
+		lights := make([]MCBlockLight, len(ps.palette))
+		for indx, s := range ps.palette {
+			id, _, err := BlockStateLegacy(s, ps.dataVersion)
+			switch {
+			case s.isAir():
+				lights[indx] = blockLights[0]
+			case err != nil:
+				lights[indx] = solidBlockLight
+			default:
+				lights[indx] = w.blockLight(id)
+			}
+		}
+
+		for indx, indxPalette := range ps.blocks {
+			opacity[indx] = lights[indxPalette].Opacity
+			emission[indx] = lights[indxPalette].Emission
+		}
+
+		return
+	}
+
+	for indx := 0; indx < 4096; indx++ {
+		id, _, _ := sect.getBlock(indx)
+		l := w.blockLight(id)
+		opacity[indx] = l.Opacity
+		emission[indx] = l.Emission
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// relight works out the light of the chunks with block edits, and their neighbours
+//
+// the light of the chunks relit is worked out from scratch, over the whole height of the chunk; the chunks around them
+// are not changed, but the light they already have spreads into the chunks being relit, as it would in the game;  only
+// chunks in regions already loaded take part, since loading more regions could evict the very regions being relit
+//
+// an edit can change the light up to 15 blocks away, which is never further than the neighbouring chunks;  so, the chunks
+// relit are the ones edited and the eight around each, and the chunks around those lend their light
+//
+
+// a chunk taking part in a relight, with one entry per block, indexed by ((y * 256) + (z * 16) + x), from y 0 to 255;
+// this is the same layout as a Section's arrays, so that Section cy is entries cy * 4096 through cy * 4096 + 4095
+//
+type lightChunk struct {
+	chnk     *MCChunk
+	rgn      *MCRegion
+	relit    bool
+	opacity  []uint8
+	emission []uint8
+	sky      []uint8
+	block    []uint8
+	tops     []int
+	next     [4]int
+}
+
+// the neighbouring chunks, in the order of lightChunk.next
+//
+const (
+	lightWest = iota
+	lightEast
+	lightNorth
+	lightSouth
+)
+
+func (w *MCWorld) markRelight(cx int, cz int) {
+	if w.relightChunks == nil {
+		w.relightChunks = make(map[[2]int]bool, 0)
+	}
+	w.relightChunks[[2]int{cx, cz}] = true
+}
+
+// loadedChunk returns the chunk at chunk-coordinates cx, cz, decoding it if need be, or nil if its region is not loaded or
+// it has not been generated
+//
+func (w *MCWorld) loadedChunk(cx int, cz int) (chnk *MCChunk, rgn *MCRegion, err error) {
+	rx := int(math.Floor(float64(cx) / 32.0))
+	rz := int(math.Floor(float64(cz) / 32.0))
+
+	rgn = w.Regions[[2]int{rx, rz}]
+	if rgn == nil {
+		return
+	}
+
+	chnk = &rgn.Chunks[((cz - (rz * 32)) * 32) + (cx - (rx * 32))]
+	if !chnk.Decoded && chnk.Raw == nil {
+		chnk = nil
+		return
+	}
+
+	err = w.DecodeChunk(chnk)
+
+	return
+}
+
+func (w *MCWorld) relight() (err error) {
+	if len(w.relightChunks) == 0 {
+		return
+	}
+
+	lcs := make(lightChunks, 0)
+	lcIndx := make(map[[2]int]int, 0)
+
+	addChunk := func(cx int, cz int, relit bool) (err error) {
+		if indx, okay := lcIndx[[2]int{cx, cz}]; okay {
+			lcs[indx].relit = lcs[indx].relit || relit
+			return
+		}
+
+		chnk, rgn, err := w.loadedChunk(cx, cz)
+		if err != nil || chnk == nil {
+			return
+		}
+
+		lcIndx[[2]int{cx, cz}] = len(lcs)
+		lcs = append(lcs, &lightChunk{chnk: chnk, rgn: rgn, relit: relit})
+
+		return
+	}
+
+	// the chunks to relight, in a fixed order, so that runs are repeatable; then the ones around them
+	keys := make([][2]int, 0)
+	for key := range w.relightChunks {
+		for dz := -1; dz <= 1; dz++ {
+			for dx := -1; dx <= 1; dx++ {
+				keys = append(keys, [2]int{key[0] + dx, key[1] + dz})
+			}
+		}
+	}
+	sortChunkKeys(keys)
+	for _, key := range keys {
+		err = addChunk(key[0], key[1], true)
+		if err != nil {
+			return
+		}
+	}
+
+	keys = keys[:0]
+	for _, lc := range lcs {
+		for dz := -1; dz <= 1; dz++ {
+			for dx := -1; dx <= 1; dx++ {
+				keys = append(keys, [2]int{lc.chnk.CX + dx, lc.chnk.CZ + dz})
+			}
+		}
+	}
+	sortChunkKeys(keys)
+	for _, key := range keys {
+		err = addChunk(key[0], key[1], false)
+		if err != nil {
+			return
+		}
+	}
+
+	for _, lc := range lcs {
+		for dir, offset := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			lc.next[dir] = -1
+			if indx, okay := lcIndx[[2]int{lc.chnk.CX + offset[0], lc.chnk.CZ + offset[1]}]; okay {
+				lc.next[dir] = indx
+			}
+		}
+
+		if lc.relit {
+			err = w.readBlockLights(lc)
+		} else {
+			err = readLightLevels(lc)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	// sky light first : straight down from the sky, to the highest block that is not fully see-through, then spreading out
+	// from there; only the blocks beside a taller column, or beside a chunk not being relit, can spread sky light to any
+	// block that does not already have it from above
+	queue := make([]uint32, 0)
+	for indxLC, lc := range lcs {
+		if !lc.relit {
+			continue
+		}
+
+		for indxColumn := 0; indxColumn < 256; indxColumn++ {
+			for y := lc.tops[indxColumn] + 1; y < 256; y++ {
+				lc.sky[(y * 256) + indxColumn] = 15
+			}
+
+			highest := lc.tops[indxColumn]
+			for _, nb := range lcs.neighbourColumns(indxLC, indxColumn) {
+				if nb[0] >= 0 && lcs[nb[0]].relit && lcs[nb[0]].tops[nb[1]] > highest {
+					highest = lcs[nb[0]].tops[nb[1]]
+				}
+			}
+			if highest <= lc.tops[indxColumn] {
+				highest = lc.tops[indxColumn] + 1
+			}
+			for y := lc.tops[indxColumn] + 1; y <= highest && y < 256; y++ {
+				queue = append(queue, (uint32(indxLC) << 16) | uint32((y * 256) + indxColumn))
+			}
+		}
+	}
+	queue = lcs.seedFromBoundary(queue, func(lc *lightChunk) []uint8 { return lc.sky })
+	lcs.spread(queue, func(lc *lightChunk) []uint8 { return lc.sky })
+
+	// then block light : from each block that gives off light
+	queue = queue[:0]
+	for indxLC, lc := range lcs {
+		if !lc.relit {
+			continue
+		}
+
+		for indx, valu := range lc.emission {
+			if valu > 0 {
+				lc.block[indx] = valu
+				queue = append(queue, (uint32(indxLC) << 16) | uint32(indx))
+			}
+		}
+	}
+	queue = lcs.seedFromBoundary(queue, func(lc *lightChunk) []uint8 { return lc.block })
+	lcs.spread(queue, func(lc *lightChunk) []uint8 { return lc.block })
+
+	for _, lc := range lcs {
+		if lc.relit {
+			lc.writeLightLevels()
+			lc.rgn.Dirty = true
+			w.Stats.ChunksRelit++
+		}
+	}
+
+	w.relightChunks = nil
+
+	return
+}
+
+func sortChunkKeys(keys [][2]int) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][1] != keys[j][1] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+}
+
+// for a chunk being relit, the opacity and emission of each of its blocks, and the height of each of its columns
+//
+func (w *MCWorld) readBlockLights(lc *lightChunk) (err error) {
+	lc.opacity = make([]uint8, 65536)
+	lc.emission = make([]uint8, 65536)
+	lc.sky = make([]uint8, 65536)
+	lc.block = make([]uint8, 65536)
+
+	for cy := 0; cy < 16; cy++ {
+		var sect sectionCodec
+		sect, err = lc.chnk.sectionAt(cy)
+		if err != nil {
+			return
+		}
+
+		// a missing Section is all air, which is already all zeroes
+		if sect != nil {
+			w.sectionLights(sect, lc.opacity[cy * 4096 : (cy + 1) * 4096], lc.emission[cy * 4096 : (cy + 1) * 4096])
+		}
+	}
+
+	lc.tops = lightTops(lc.opacity)
+
+	return
+}
+
+// the height of each column, as the y of its highest block that is not fully see-through, or -1 if there is none
+//
+func lightTops(opacity []uint8) (tops []int) {
+	tops = make([]int, 256)
+	for indxColumn := range tops {
+		tops[indxColumn] = -1
+		for y := 255; y >= 0; y-- {
+			if opacity[(y * 256) + indxColumn] > 0 {
+				tops[indxColumn] = y
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// for a chunk around those being relit, the light it has already; a missing Section is open to the sky, and a Section
+// without light arrays (which since 1.14 can happen) is taken to be dark
+//
+func readLightLevels(lc *lightChunk) (err error) {
+	lc.sky = make([]uint8, 65536)
+	lc.block = make([]uint8, 65536)
+
+	for cy := 0; cy < 16; cy++ {
+		section := lc.chnk.sectionNBT(cy)
+		if section == nil {
+			for indx := cy * 4096; indx < (cy + 1) * 4096; indx++ {
+				lc.sky[indx] = 15
+			}
+			continue
+		}
+
+		if dataSkyLight := NBTChild(section, "SkyLight"); dataSkyLight != nil {
+			for indx := 0; indx < 4096; indx++ {
+				lc.sky[(cy * 4096) + indx] = getNybble(dataSkyLight.Data.([]byte), indx)
+			}
+		}
+		if dataBlockLight := NBTChild(section, "BlockLight"); dataBlockLight != nil {
+			for indx := 0; indx < 4096; indx++ {
+				lc.block[(cy * 4096) + indx] = getNybble(dataBlockLight.Data.([]byte), indx)
+			}
+		}
+	}
+
+	return
+}
+
+// the light levels are written into every Section the chunk has; SkyLight only where the Section keeps it, since those
+// of the Nether do not
+//
+func (lc *lightChunk) writeLightLevels() {
+	for cy := 0; cy < 16; cy++ {
+		section := lc.chnk.sectionNBT(cy)
+		if section == nil {
+			continue
+		}
+
+		if NBTChild(section, "SkyLight") != nil {
+			skylight := make([]byte, 2048)
+			for indx := 0; indx < 4096; indx++ {
+				setNybble(skylight, indx, lc.sky[(cy * 4096) + indx])
+			}
+			setChild(section, nbt.NBT{nbt.TAG_Byte_Array, 0, "SkyLight", 2048, skylight})
+		}
+
+		blocklight := make([]byte, 2048)
+		for indx := 0; indx < 4096; indx++ {
+			setNybble(blocklight, indx, lc.block[(cy * 4096) + indx])
+		}
+		setChild(section, nbt.NBT{nbt.TAG_Byte_Array, 0, "BlockLight", 2048, blocklight})
+	}
+
+	// the light is worked out now, so Minecraft need not do it again; since 1.14, though, Minecraft also keeps light
+	// above and below the chunk's Sections, which we do not work out, so isLightOn is left for Minecraft to redo
+	if dataLightPopulated := lc.chnk.ChunkDataRefs["LightPopulated"]; dataLightPopulated != nil {
+		dataLightPopulated.Data = byte(1)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// spreading light from block to block, through every chunk being relit
+
+type lightChunks []*lightChunk
+
+// neighbourBlocks returns the lightChunk and index of each of the six blocks around the block at index indx of lcs[indxLC],
+// or -1 for those outside every lightChunk
+//
+func (lcs lightChunks) neighbourBlocks(indxLC int, indx int) (nbs [6][2]int) {
+	lc := lcs[indxLC]
+	x := indx % 16
+	z := (indx / 16) % 16
+	y := indx / 256
+
+	nbs[0] = lcs.step(lc, indxLC, x > 0, lightWest, indx - 1, indx + 15)
+	nbs[1] = lcs.step(lc, indxLC, x < 15, lightEast, indx + 1, indx - 15)
+	nbs[2] = lcs.step(lc, indxLC, z > 0, lightNorth, indx - 16, indx + 240)
+	nbs[3] = lcs.step(lc, indxLC, z < 15, lightSouth, indx + 16, indx - 240)
+
+	nbs[4] = [2]int{-1, 0}
+	if y > 0 {
+		nbs[4] = [2]int{indxLC, indx - 256}
+	}
+	nbs[5] = [2]int{-1, 0}
+	if y < 255 {
+		nbs[5] = [2]int{indxLC, indx + 256}
+	}
+
+	return
+}
+
+func (lcs lightChunks) step(lc *lightChunk, indxLC int, within bool, dir int, indxWithin int, indxBeyond int) [2]int {
+	// a block at the edge of a chunk is beside a block at the opposite edge of the next chunk over
+	if within {
+		return [2]int{indxLC, indxWithin}
+	}
+
+	return [2]int{lc.next[dir], indxBeyond}
+}
+
+// neighbourColumns is the same as neighbourBlocks, for the four columns beside a column
+//
+func (lcs lightChunks) neighbourColumns(indxLC int, indxColumn int) (nbs [4][2]int) {
+	nbBlocks := lcs.neighbourBlocks(indxLC, indxColumn)
+	copy(nbs[:], nbBlocks[:4])
+
+	return
+}
+
+// each block at the edge of the chunks being relit takes what light it would from the block beside it, in a chunk that is
+// not being relit
+//
+func (lcs lightChunks) seedFromBoundary(queue []uint32, levels func(lc *lightChunk) []uint8) []uint32 {
+	for indxLC, lc := range lcs {
+		if !lc.relit {
+			continue
+		}
+
+		for indx := 0; indx < 65536; indx++ {
+			x := indx % 16
+			z := (indx / 16) % 16
+			if x != 0 && x != 15 && z != 0 && z != 15 {
+				continue
+			}
+
+			nbs := lcs.neighbourBlocks(indxLC, indx)
+			for _, nb := range nbs[:4] {
+				if nb[0] < 0 || lcs[nb[0]].relit {
+					continue
+				}
+
+				if valu := lightThrough(levels(lcs[nb[0]])[nb[1]], lc.opacity[indx]); valu > levels(lc)[indx] {
+					levels(lc)[indx] = valu
+					queue = append(queue, (uint32(indxLC) << 16) | uint32(indx))
+				}
+			}
+		}
+	}
+
+	return queue
+}
+
+// light going into a block loses at least one level, and more for blocks that are only partly see-through
+//
+func lightThrough(valu uint8, opacity uint8) uint8 {
+	if opacity < 1 {
+		opacity = 1
+	}
+	if valu <= opacity {
+		return 0
+	}
+
+	return valu - opacity
+}
+
+func (lcs lightChunks) spread(queue []uint32, levels func(lc *lightChunk) []uint8) {
+	for head := 0; head < len(queue); head++ {
+		entry := queue[head]
+
+		indxLC := int(entry >> 16)
+		indx := int(entry & 0xFFFF)
+		valu := levels(lcs[indxLC])[indx]
+
+		for _, nb := range lcs.neighbourBlocks(indxLC, indx) {
+			if nb[0] < 0 || !lcs[nb[0]].relit {
+				continue
+			}
+
+			lc := lcs[nb[0]]
+			if lc.opacity[nb[1]] >= 15 {
+				continue
+			}
+
+			if nbValu := lightThrough(valu, lc.opacity[nb[1]]); nbValu > levels(lc)[nb[1]] {
+				levels(lc)[nb[1]] = nbValu
+				queue = append(queue, (uint32(nb[0]) << 16) | uint32(nb[1]))
+			}
+		}
+	}
+}
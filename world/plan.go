@@ -0,0 +1,156 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// MCEditPlan
+//
+// an edit plan is a record of what a render would do, resolved all the way down to where in the region files each edit
+// lands;  with -dryrun, the Edit* functions carry on as usual, in memory, but also add an entry here for each edit, and
+// nothing is saved;  afterwards, the plan can be reported for review
+//
+// entry kinds :
+//     section     : a new Section, added to a chunk so that blocks can be placed in it
+//     block       : a block, replacing whatever block was there
+//     blockentity : a blockentity, possibly duplicating one that is already there
+//     entity      : an entity, added to whatever entities are already there
+//     biome       : the biome of a column (OldID and NewID being the biome ids), replacing whatever biome was there
+//
+type MCEditPlan struct {
+	Entries []MCEditPlanEntry
+}
+
+type MCEditPlanEntry struct {
+	Kind      string
+	X         int
+	Y         int
+	Z         int
+	RX        int
+	RZ        int
+	IndxChunk int
+	Section   int
+	IndxBlock int
+	OldID     uint16
+	OldData   uint8
+	NewID     uint16
+	NewData   uint8
+	OldState  string
+	NewState  string
+	Name      string
+	Duplicate bool
+}
+
+// this fills in the region, chunk, section and block indexes for an entry at world-coordinates x, y, z; the calculations
+// are the same as those in EditBlock
+//
+func newMCEditPlanEntry(kind string, x int, y int, z int) (entry MCEditPlanEntry) {
+	entry = MCEditPlanEntry{Kind: kind, X: x, Y: y, Z: z}
+
+	entry.RX = int(math.Floor(float64(x) / 512.0))
+	entry.RZ = int(math.Floor(float64(z) / 512.0))
+
+	cx := int(math.Floor(float64(x) / 16.0))
+	cy := int(                   y  / 16   )
+	cz := int(math.Floor(float64(z) / 16.0))
+	entry.IndxChunk = ((cz - (entry.RZ * 32)) * 32) + (cx - (entry.RX * 32))
+	entry.Section = cy
+
+	ix := x - (cx * 16)
+	iy := y       % 16
+	iz := z - (cz * 16)
+	entry.IndxBlock = (iy * 256) + (iz * 16) + ix
+
+	return
+}
+
+func (p *MCEditPlan) Record(entry MCEditPlanEntry) {
+	p.Entries = append(p.Entries, entry)
+}
+
+// the report lists every edit that changes something, followed by a summary; blocks are described by the describe
+// function given, so that the caller can name them as it likes (e.g., by blueprint glyph), or by id:data if it is nil
+//
+func (p *MCEditPlan) Report(out io.Writer, describe func(id uint16, data uint8) string) {
+	if describe == nil {
+		describe = func(id uint16, data uint8) string { return fmt.Sprintf("%d:%d", id, data) }
+	}
+
+	var qtySections, qtyBlocks, qtyBlocksUnchanged int
+	var qtyBlockEntities, qtyBlockEntitiesDuplicate, qtyEntities int
+	var qtyBiomes, qtyBiomesUnchanged int
+
+	regions := make(map[[2]int]bool, 0)
+	chunks := make(map[[3]int]bool, 0)
+
+	for _, entry := range p.Entries {
+		regions[[2]int{entry.RX, entry.RZ}] = true
+		chunks[[3]int{entry.RX, entry.RZ, entry.IndxChunk}] = true
+
+		where := fmt.Sprintf("r.%d.%d.mca  chunk %4d  section %2d  index %4d  at %d, %d, %d", entry.RX, entry.RZ, entry.IndxChunk, entry.Section, entry.IndxBlock, entry.X, entry.Y, entry.Z)
+
+		switch entry.Kind {
+		case "section":
+			qtySections++
+			fmt.Fprintf(out, "%s : new section\n", where)
+
+		case "block":
+			if entry.OldID == entry.NewID && entry.OldData == entry.NewData && entry.OldState == entry.NewState {
+				qtyBlocksUnchanged++
+				continue
+			}
+			qtyBlocks++
+
+			// blocks placed or found by block-state, rather than by id and data, are described by their block-state
+			oldBlock := describe(entry.OldID, entry.OldData)
+			if entry.OldState != "" {
+				oldBlock = entry.OldState
+			}
+			newBlock := describe(entry.NewID, entry.NewData)
+			if entry.NewState != "" {
+				newBlock = entry.NewState
+			}
+			fmt.Fprintf(out, "%s : block %s -> %s\n", where, oldBlock, newBlock)
+
+		case "blockentity":
+			qtyBlockEntities++
+			if entry.Duplicate {
+				qtyBlockEntitiesDuplicate++
+				fmt.Fprintf(out, "%s : blockentity %s  DUPLICATES AN EXISTING BLOCKENTITY\n", where, entry.Name)
+			} else {
+				fmt.Fprintf(out, "%s : blockentity %s\n", where, entry.Name)
+			}
+
+		case "entity":
+			qtyEntities++
+			fmt.Fprintf(out, "%s : entity %s\n", where, entry.Name)
+
+		case "biome":
+			if entry.OldID == entry.NewID {
+				qtyBiomesUnchanged++
+				continue
+			}
+			qtyBiomes++
+			fmt.Fprintf(out, "%s : biome %d -> %d\n", where, entry.OldID, entry.NewID)
+		}
+	}
+
+	fmt.Fprintf(out, "\n")
+	fmt.Fprintf(out, "regions touched            : %d\n", len(regions))
+	fmt.Fprintf(out, "chunks touched             : %d\n", len(chunks))
+	fmt.Fprintf(out, "sections created           : %d\n", qtySections)
+	fmt.Fprintf(out, "blocks overwritten         : %d\n", qtyBlocks)
+	fmt.Fprintf(out, "blocks already as planned  : %d\n", qtyBlocksUnchanged)
+	fmt.Fprintf(out, "blockentities added        : %d\n", qtyBlockEntities)
+	fmt.Fprintf(out, "blockentities duplicated   : %d\n", qtyBlockEntitiesDuplicate)
+	fmt.Fprintf(out, "entities spawned           : %d\n", qtyEntities)
+	fmt.Fprintf(out, "biomes changed             : %d\n", qtyBiomes)
+	fmt.Fprintf(out, "biomes already as planned  : %d\n", qtyBiomesUnchanged)
+	fmt.Fprintf(out, "\n")
+}
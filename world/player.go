@@ -0,0 +1,303 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/landru27/nbt"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// MCPlayer
+//
+// each player who has visited a world has a file of their own in the save folder, playerdata/<uuid>.dat, of GZip-compressed
+// NBT;  in a single-player world, though, the player is kept in level.dat, as its Player compound, which Minecraft reads in
+// preference to the player's own file
+//
+// a player's inventory is its Inventory list, and its ender chest is its EnderItems list; both are lists of items, as in a
+// chest, each with the Slot it is in :
+//
+//     Inventory  : slots 0 to 8 are the hotbar, and 9 to 35 the rest of the inventory (armour and the off-hand slot, at
+//                  100 to 103 and -106, are left alone here)
+//     EnderItems : slots 0 to 26
+//
+type MCPlayer struct {
+	UUID     string
+	Filename string
+	Data     *nbt.NBT
+
+	root    nbt.NBT
+	inLevel bool
+}
+
+// the slots each of a player's item lists has room for
+//
+var playerItemSlots = map[string]int{
+	"Inventory":  36,
+	"EnderItems": 27,
+}
+
+// ReadPlayer reads the player with the given UUID from playerdata, unless it is the single-player world's player, who is
+// read from level.dat, as Minecraft reads them;  with no UUID, the single-player world's player is read, or, failing
+// that, the only player in playerdata
+//
+func (w *MCWorld) ReadPlayer(uuid string) (p *MCPlayer, err error) {
+	p = &MCPlayer{UUID: uuid}
+
+	var host string
+	if w.Level != nil {
+		if nbtHost := NBTChild(&w.Level.Data, "Player"); nbtHost != nil {
+			host = playerUUID(nbtHost)
+			p.inLevel = uuid == "" || sameUUID(uuid, host)
+		}
+	}
+
+	if p.inLevel {
+		p.Filename = filepath.Join(w.PathSave, "level.dat")
+		if p.UUID == "" {
+			p.UUID = host
+		}
+	} else if uuid == "" {
+		var files []string
+		files, err = filepath.Glob(filepath.Join(w.PathSave, "playerdata", "*.dat"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) != 1 {
+			return nil, fmt.Errorf("there are %d players in [%s]; name one by UUID", len(files), filepath.Join(w.PathSave, "playerdata"))
+		}
+
+		p.Filename = files[0]
+		p.UUID = strings.TrimSuffix(filepath.Base(files[0]), ".dat")
+	} else {
+		p.Filename = filepath.Join(w.PathSave, "playerdata", uuid + ".dat")
+	}
+
+	fh, err := os.Open(p.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read player [%s] [%s]", p.UUID, err)
+	}
+	defer fh.Close()
+
+	rdr, err := gzip.NewReader(fh)
+	if err != nil {
+		return nil, fmt.Errorf("unable to uncompress [%s] [%s]", p.Filename, err)
+	}
+
+	p.root, err = nbt.ReadNBTData(rdr, nbt.TAG_NULL, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse [%s] [%s]", p.Filename, err)
+	}
+
+	// in level.dat, the player is within the Data compound
+	p.Data = &p.root
+	if p.inLevel {
+		p.Data = NBTChild(NBTChild(&p.root, "Data"), "Player")
+		if p.Data == nil {
+			return nil, fmt.Errorf("[%s] has no Player compound", p.Filename)
+		}
+	}
+
+	return
+}
+
+// a player's UUID is kept as any entity's is (see entityUUID);  it is written the way the player's file in playerdata is
+// named, e.g., '069a79f4-44e9-4726-a5be-fca90e38aaf5'
+//
+func playerUUID(data *nbt.NBT) (uuid string) {
+	valuMost, valuLeast, okay := entityUUID(data)
+	if !okay {
+		return
+	}
+	most, least := uint64(valuMost), uint64(valuLeast)
+
+	uuid = fmt.Sprintf("%08x-%04x-%04x-%04x-%012x", most >> 32, (most >> 16) & 0xffff, most & 0xffff, least >> 48, least & 0xffffffffffff)
+
+	return
+}
+
+// UUIDs are the same whatever their case, and with or without their dashes
+//
+func sameUUID(a string, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+
+	return strings.EqualFold(strings.Replace(a, "-", "", -1), strings.Replace(b, "-", "", -1))
+}
+
+// GiveItems puts items (as found in a chest's Items list, each with its Slot) into the player's Inventory or EnderItems :
+// each into the same slot, if the player has nothing there, or else into the first slot the player has free, counted as
+// moved;  nothing the player has is replaced, and if there is not room for all of the items, none of them are given
+//
+func (p *MCPlayer) GiveItems(list string, items []nbt.NBT) (given int, moved int, err error) {
+	slots, okay := playerItemSlots[list]
+	if !okay {
+		return 0, 0, fmt.Errorf("unknown player item list [%s]; expected 'Inventory' or 'EnderItems'", list)
+	}
+
+	dataList := NBTChild(p.Data, list)
+	if dataList == nil {
+		p.Data.Data = append(p.Data.Data.([]nbt.NBT), nbt.NBT{nbt.TAG_List, nbt.TAG_Compound, list, 0, make([]nbt.NBT, 0)})
+		p.Data.Size = uint32(len(p.Data.Data.([]nbt.NBT)))
+		dataList = NBTChild(p.Data, list)
+	}
+	if dataList.Type != nbt.TAG_List {
+		return 0, 0, fmt.Errorf("the player's %s is not a List", list)
+	}
+
+	// the slots the player has something in already (armour and the off-hand, beyond the slots counted, included)
+	taken := make(map[int]bool, 0)
+	if have, okay := dataList.Data.([]nbt.NBT); okay {
+		for indx := range have {
+			if slot, errHave := itemSlot(&have[indx]); errHave == nil {
+				taken[slot] = true
+			}
+		}
+	}
+
+	// settle on a slot for every item before changing any, so that items the player has no room for leave the player as
+	// they were;  first, the items whose own slot is free, and then the rest, into whatever slots are left
+	placed := make([]int, len(items))
+	for indx := range items {
+		slot, err := itemSlot(&items[indx])
+		if err != nil {
+			return 0, 0, err
+		}
+		if slot < 0 || slot >= slots {
+			return 0, 0, fmt.Errorf("slot %d is beyond the %d slots of the player's %s", slot, slots, list)
+		}
+
+		placed[indx] = -1
+		if !taken[slot] {
+			placed[indx] = slot
+			taken[slot] = true
+		}
+	}
+
+	free := 0
+	for indx := range items {
+		if placed[indx] >= 0 {
+			continue
+		}
+		for free < slots && taken[free] {
+			free++
+		}
+		if free >= slots {
+			return 0, 0, fmt.Errorf("the player's %s has no room for %d of the %d items; nothing was given", list, countUnplaced(placed[indx:]), len(items))
+		}
+
+		placed[indx] = free
+		taken[free] = true
+		moved++
+	}
+
+	for indx := range items {
+		item, _ := items[indx].DeepCopy()
+		NBTChild(item, "Slot").Data = byte(placed[indx])
+
+		dataList.Data = append(dataList.Data.([]nbt.NBT), *item)
+		dataList.List = nbt.TAG_Compound
+		dataList.Size = uint32(len(dataList.Data.([]nbt.NBT)))
+		given++
+	}
+
+	return
+}
+
+func countUnplaced(placed []int) (count int) {
+	for _, elem := range placed {
+		if elem < 0 {
+			count++
+		}
+	}
+
+	return
+}
+
+// an item's Slot is a signed byte
+//
+func itemSlot(item *nbt.NBT) (slot int, err error) {
+	nbtSlot := NBTChild(item, "Slot")
+	if nbtSlot == nil {
+		return 0, fmt.Errorf("an item with no Slot")
+	}
+
+	slot = int(int8(nbtSlot.Data.(byte)))
+
+	return
+}
+
+// SavePlayer writes the player back out, the same way, and with the same backups, as a region file
+//
+func (w *MCWorld) SavePlayer(p *MCPlayer) (err error) {
+	err = w.checkSession()
+	if err != nil {
+		return
+	}
+
+	var bufNBT bytes.Buffer
+	gz := gzip.NewWriter(&bufNBT)
+	err = nbt.WriteNBTData(gz, &p.root)
+	if err != nil {
+		return
+	}
+	err = gz.Close()
+	if err != nil {
+		return
+	}
+
+	err = writeRegionFile(p.Filename, bufNBT.Bytes(), true)
+
+	return
+}
+
+// Position reads where the player stood when they last left the world, and which way they faced :  a yaw of 0 is facing
+// south, 90 west, 180 north and 270 east (or, as Minecraft also writes it, -90)
+//
+func (p *MCPlayer) Position() (x float64, y float64, z float64, yaw float32, err error) {
+	dataPos := NBTChild(p.Data, "Pos")
+	dataRotation := NBTChild(p.Data, "Rotation")
+	if dataPos == nil || dataRotation == nil {
+		return 0, 0, 0, 0, fmt.Errorf("player [%s] has no Pos or Rotation", p.UUID)
+	}
+
+	pos, okayPos := dataPos.Data.([]nbt.NBT)
+	rotation, okayRotation := dataRotation.Data.([]nbt.NBT)
+	if !okayPos || !okayRotation || len(pos) != 3 || len(rotation) != 2 {
+		return 0, 0, 0, 0, fmt.Errorf("player [%s] has a Pos or Rotation not laid out as expected", p.UUID)
+	}
+
+	x = pos[0].Data.(float64)
+	y = pos[1].Data.(float64)
+	z = pos[2].Data.(float64)
+	yaw = rotation[0].Data.(float32)
+
+	return
+}
+
+// Dimension names the dimension the player is in, as 'overworld', 'nether' or 'end';  Dimension was a number until
+// 1.16, and has been a name since
+//
+func (p *MCPlayer) Dimension() (dimension string) {
+	dataDimension := NBTChild(p.Data, "Dimension")
+	if dataDimension == nil {
+		return "overworld"
+	}
+
+	switch valu := dataDimension.Data.(type) {
+	case int32:
+		dimension = map[int32]string{0: "overworld", -1: "nether", 1: "end"}[valu]
+	case string:
+		dimension = map[string]string{"minecraft:overworld": "overworld", "minecraft:the_nether": "nether", "minecraft:the_end": "end"}[valu]
+	}
+
+	return
+}
@@ -0,0 +1,75 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"math"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// MCFormatProfile
+//
+// the DataVersion that Minecraft writes into level.dat and into each chunk tells us which version of the game saved them,
+// and so how they are laid out;  a format profile names a range of DataVersions laid out the same way, and says whether,
+// and how, we can edit them :
+//
+//     Codec "legacy"  : Sections of Blocks, Data and Add arrays; blueprint glyphs are written as their ids and data
+//     Codec "palette" : Sections of a Palette and BlockStates; blueprint glyphs are translated to block-states (see
+//                       MCBlockState), so the same legend serves both
+//
+// from 1.17 on, entities are no longer kept in each chunk's Entities list, but in region files of their own, under the
+// world's entities directory;  those are not read here, so entities can be neither placed into nor captured from such
+// a world, and the attempt is refused rather than passed over in silence (rendering with -skipentities leaves them out)
+//
+// from 1.18 on, chunks have no Level compound, Sections have become sections of block_states, and the world reaches
+// below y 0 and above y 255;  none of that is understood here, so those worlds are refused outright, rather than being
+// half-read and failing somewhere in the middle of an edit
+//
+type MCFormatProfile struct {
+	Name           string
+	MinDataVersion int
+	MaxDataVersion int
+	Codec          string
+	Supported      bool
+	ChunkEntities  bool
+}
+
+// worlds from before 1.9 have no DataVersion at all, which reads as 0
+//
+var formatProfiles = []MCFormatProfile{
+	{"1.2 to 1.8.9", 0, 0, "legacy", true, true},
+	{"1.9 to 1.12.2", 1, dataVersionFlattening - 1, "legacy", true, true},
+	{"1.13 to 1.15.2", dataVersionFlattening, dataVersionTightPacking - 1, "palette", true, true},
+	{"1.16 to 1.16.5", dataVersionTightPacking, dataVersionEntityRegions - 1, "palette", true, true},
+	{"1.17 to 1.17.1", dataVersionEntityRegions, dataVersionNoLevel - 1, "palette", true, false},
+	{"1.18 and later", dataVersionNoLevel, math.MaxInt32, "", false, false},
+}
+
+// the DataVersion at which (in 20w45a) entities moved out of the chunk, into entities/r.X.Z.mca
+//
+const dataVersionEntityRegions = 2681
+
+// the DataVersion at which (in 21w43a) chunks lost their Level compound
+//
+const dataVersionNoLevel = 2844
+
+// FormatProfile finds the format profile of a DataVersion, with an error for one we are unable to edit
+//
+func FormatProfile(dataVersion int) (p MCFormatProfile, err error) {
+	for _, elem := range formatProfiles {
+		if dataVersion >= elem.MinDataVersion && dataVersion <= elem.MaxDataVersion {
+			p = elem
+			break
+		}
+	}
+
+	if p.Name == "" {
+		err = fmt.Errorf("DataVersion %d is not one that any version of Minecraft has written", dataVersion)
+	} else if !p.Supported {
+		err = fmt.Errorf("DataVersion %d is a Minecraft %s format, which worldcraft is unable to edit", dataVersion, p.Name)
+	}
+
+	return
+}
@@ -0,0 +1,551 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+
+	"github.com/landru27/nbt"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// Section codecs
+//
+// a chunk is stored as a stack of up to 16 Sections, each 16x16x16 blocks;  there have been two ways of storing the blocks
+// of a Section :
+//
+//     legacy  (to 1.12) : a Blocks array of one byte per block, for the low 8 bits of the block id, an optional Add
+//                         array of one nybble per block, for the high 4 bits, and a Data array of one nybble per block,
+//                         for the data value
+//     palette (1.13 on) : a Palette listing each of the block-states found in the Section, and a BlockStates array of
+//                         indexes into the Palette, one per block, packed into as few bits as the Palette size allows
+//
+// a section codec reads and writes the blocks of one Section in whichever way it is stored, by id and data or by block-
+// state, translating between the two as needed (see MCBlockState);  the palette codec unpacks its Section the first
+// time it is needed, and packs it back up only when the chunk is saved (see flushSections)
+//
+type sectionCodec interface {
+	getBlock(indxBlock int) (id uint16, data uint8, err error)
+	setBlock(indxBlock int, id uint16, data uint8) (err error)
+	getState(indxBlock int) (s MCBlockState)
+	setState(indxBlock int, s MCBlockState) (err error)
+	flush(section *nbt.NBT)
+}
+
+// the DataVersions at which the storage of blocks changed :  17w47a introduced the palette, and 20w17a stopped packed
+// indexes from spanning two longs
+//
+const dataVersionFlattening = 1451
+const dataVersionTightPacking = 2529
+
+// a Section's Y is a signed byte; since 1.14, chunks can have a Section at Y -1, holding only light
+//
+func sectionY(section *nbt.NBT) (cy int, okay bool) {
+	nbtY := NBTChild(section, "Y")
+	if nbtY == nil {
+		return
+	}
+
+	cy = int(int8(nbtY.Data.(byte)))
+	okay = true
+
+	return
+}
+
+// Sections are not necessarily listed in order, nor is every height present, so we find a Section by its Y rather than by
+// its place in the list
+//
+func (c *MCChunk) sectionNBT(cy int) (rslt *nbt.NBT) {
+	dataSections := c.ChunkDataRefs["Sections"]
+	if dataSections == nil {
+		return
+	}
+
+	for indx := range dataSections.Data.([]nbt.NBT) {
+		elem := &dataSections.Data.([]nbt.NBT)[indx]
+		if y, okay := sectionY(elem); okay && y == cy {
+			rslt = elem
+			return
+		}
+	}
+
+	return
+}
+
+// sectionAt returns the codec for the blocks of the Section at height cy, or nil if the chunk has no blocks there
+//
+func (c *MCChunk) sectionAt(cy int) (sect sectionCodec, err error) {
+	if sect = c.sections[cy]; sect != nil {
+		return
+	}
+
+	section := c.sectionNBT(cy)
+	if section == nil {
+		return
+	}
+
+	if dataBlocks := NBTChild(section, "Blocks"); dataBlocks != nil {
+		dataBlockData := NBTChild(section, "Data")
+		if dataBlockData == nil {
+			err = fmt.Errorf("section %d of chunk %d, %d has Blocks but no Data", cy, c.CX, c.CZ)
+			return
+		}
+		ls := &legacySection{dataVersion: c.DataVersion}
+		var okayBlocks, okayData, okayAdd bool
+		ls.blocks, okayBlocks = dataBlocks.Data.([]byte)
+		ls.data, okayData = dataBlockData.Data.([]byte)
+		okayAdd = true
+		if dataAdd := NBTChild(section, "Add"); dataAdd != nil {
+			ls.add, okayAdd = dataAdd.Data.([]byte)
+		}
+		if !okayBlocks || !okayData || !okayAdd || len(ls.blocks) != 4096 || len(ls.data) != 2048 || (ls.add != nil && len(ls.add) != 2048) {
+			err = fmt.Errorf("section %d of chunk %d, %d has Blocks, Data or Add arrays not laid out as in 1.2 to 1.12.2", cy, c.CX, c.CZ)
+			return
+		}
+		sect = ls
+	} else if NBTChild(section, "Palette") != nil {
+		sect, err = newPaletteSection(section, c.DataVersion)
+		if err != nil {
+			err = fmt.Errorf("section %d of chunk %d, %d : %s", cy, c.CX, c.CZ, err)
+			return
+		}
+	} else {
+		return
+	}
+
+	if c.sections == nil {
+		c.sections = make(map[int]sectionCodec, 0)
+	}
+	c.sections[cy] = sect
+
+	return
+}
+
+// addSection gives the chunk an all-air Section at height cy, in the chunk's own format;  since 1.14, a Section can exist
+// with only light in it, in which case we add the blocks to that Section, rather than adding another
+//
+func (c *MCChunk) addSection(cy int) {
+	var blockdata []nbt.NBT
+	if c.DataVersion >= dataVersionFlattening {
+		air := MCBlockState{Name: "minecraft:air"}
+		blockdata = []nbt.NBT{
+			nbt.NBT{nbt.TAG_List, nbt.TAG_Compound, "Palette", 1, []nbt.NBT{air.toNBT()}},
+			nbt.NBT{nbt.TAG_Long_Array, 0, "BlockStates", 256, make([]int64, 256)},
+		}
+	} else {
+		blockdata = []nbt.NBT{
+			nbt.NBT{nbt.TAG_Byte_Array, 0, "Blocks", 4096, make([]byte, 4096)},
+			nbt.NBT{nbt.TAG_Byte_Array, 0, "Data", 2048, make([]byte, 2048)},
+			nbt.NBT{nbt.TAG_Byte_Array, 0, "Add", 2048, make([]byte, 2048)},
+		}
+	}
+
+	if section := c.sectionNBT(cy); section != nil {
+		section.Data = append(section.Data.([]nbt.NBT), blockdata...)
+		section.Size = uint32(len(section.Data.([]nbt.NBT)))
+		return
+	}
+
+	sectiondata := []nbt.NBT{nbt.NBT{nbt.TAG_Byte, 0, "Y", 0, byte(cy)}}
+	sectiondata = append(sectiondata, blockdata...)
+	sectiondata = append(sectiondata, nbt.NBT{nbt.TAG_Byte_Array, 0, "SkyLight", 2048, make([]byte, 2048)})
+	sectiondata = append(sectiondata, nbt.NBT{nbt.TAG_Byte_Array, 0, "BlockLight", 2048, make([]byte, 2048)})
+	section := nbt.NBT{nbt.TAG_Compound, 0, "LISTELEM", uint32(len(sectiondata)), sectiondata}
+
+	// Minecraft writes an empty list as a list of TAG_End; see EditBlockEntity
+	dataSections := c.ChunkDataRefs["Sections"]
+	dataSections.List = nbt.TAG_Compound
+	dataSections.Size++
+	dataSections.Data = append(dataSections.Data.([]nbt.NBT), section)
+}
+
+// removeSection takes the Section at height cy back out of the chunk; it must be the last one listed (which a Section we
+// added always is, until another is added), and anything else means the chunk is not as the journal left it
+//
+func (c *MCChunk) removeSection(cy int) (err error) {
+	dataSections := c.ChunkDataRefs["Sections"]
+	if dataSections == nil {
+		err = fmt.Errorf("chunk has no Sections to take Section %d out of", cy)
+		return
+	}
+
+	last := len(dataSections.Data.([]nbt.NBT)) - 1
+	if last < 0 {
+		err = fmt.Errorf("chunk has no Sections left to take Section %d out of", cy)
+		return
+	}
+
+	if y, okay := sectionY(&dataSections.Data.([]nbt.NBT)[last]); !okay || y != cy {
+		err = fmt.Errorf("Section %d is not the last one in the chunk, as it was when it was added", cy)
+		return
+	}
+
+	dataSections.Size--
+	dataSections.Data = dataSections.Data.([]nbt.NBT)[:last]
+	if last == 0 {
+		dataSections.List = nbt.TAG_End
+	}
+
+	delete(c.sections, cy)
+
+	return
+}
+
+// flushSections writes any unpacked Sections back into the chunkdata, ready for saving
+//
+func (c *MCChunk) flushSections() {
+	for cy, sect := range c.sections {
+		if section := c.sectionNBT(cy); section != nil {
+			sect.flush(section)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// the legacy codec, which works on the Blocks and Data arrays in place
+
+type legacySection struct {
+	blocks      []byte
+	data        []byte
+	add         []byte
+	addCreated  bool
+	dataVersion int
+}
+
+func (ls *legacySection) getBlock(indxBlock int) (id uint16, data uint8, err error) {
+	id = uint16(ls.blocks[indxBlock])
+	if ls.add != nil {
+		id += uint16(getNybble(ls.add, indxBlock)) << 8
+	}
+
+	data = getNybble(ls.data, indxBlock)
+
+	return
+}
+
+func (ls *legacySection) setBlock(indxBlock int, id uint16, data uint8) (err error) {
+	if id > 0x0FFF {
+		return fmt.Errorf("block id %d is more than 12 bits", id)
+	}
+
+	// Minecraft block IDs historically have been less than 256, but the chunkdata format actually
+	// supports 12-bit values, with the upper four bits being stored in one of the nybbles of an additional
+	// array, Add, that is half the size of the regular block ID array ...  this is compactness at the price
+	// of simplicity, but mods make good use of the higher IDs;  a Section only has an Add array if some
+	// block in it needs one, so we make one the first time a block does
+	//
+	ls.blocks[indxBlock] = byte(id & 0xFF)
+	if ls.add == nil && id > 0xFF {
+		ls.add = make([]byte, 2048)
+		ls.addCreated = true
+	}
+	if ls.add != nil {
+		setNybble(ls.add, indxBlock, uint8(id >> 8))
+	}
+
+	// more compactness at the price of simplicity :  Minecraft stores data that characterizes some
+	// blocks in another array, again as one nybble per block; a full byte-array would be both simpler
+	// and a bit more future proof, but iiwii
+	//
+	setNybble(ls.data, indxBlock, data)
+
+	return
+}
+
+func (ls *legacySection) getState(indxBlock int) (s MCBlockState) {
+	id, data, _ := ls.getBlock(indxBlock)
+	s = LegacyBlockState(id, data, ls.dataVersion)
+
+	return
+}
+
+func (ls *legacySection) setState(indxBlock int, s MCBlockState) (err error) {
+	id, data, err := BlockStateLegacy(s, ls.dataVersion)
+	if err != nil {
+		return fmt.Errorf("%s [%s]", err, s)
+	}
+
+	err = ls.setBlock(indxBlock, id, data)
+
+	return
+}
+
+// the blocks, data and (if it was already there) Add arrays are edited in place, so only an Add array we made ourselves
+// needs writing out
+//
+func (ls *legacySection) flush(section *nbt.NBT) {
+	if !ls.addCreated {
+		return
+	}
+
+	setChild(section, nbt.NBT{nbt.TAG_Byte_Array, 0, "Add", uint32(len(ls.add)), ls.add})
+	ls.addCreated = false
+}
+
+// a nybble array holds two values to a byte, the even-indexed one in the low nybble and the odd-indexed one in the high
+//
+func getNybble(arr []byte, indx int) (valu uint8) {
+	if (indx % 2) == 0 {
+		valu = arr[int(indx / 2)] & 0x0F
+	} else {
+		valu = arr[int(indx / 2)] >> 4
+	}
+
+	return
+}
+
+func setNybble(arr []byte, indx int, valu uint8) {
+	indxByte := int(indx / 2)
+	currValue := arr[indxByte]
+	var keepNybble, valuNybble byte
+	if (indx % 2) == 0 {
+		keepNybble = currValue & 0xF0
+		valuNybble = valu & 0x0F
+	} else {
+		keepNybble = currValue & 0x0F
+		valuNybble = (valu & 0x0F) << 4
+	}
+	arr[indxByte] = keepNybble + valuNybble
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// the palette codec, which unpacks the BlockStates array into one Palette index per block
+
+type paletteSection struct {
+	palette     []MCBlockState
+	paletteIndx map[string]int
+	blocks      []int
+	dataVersion int
+	dirty       bool
+}
+
+func newPaletteSection(section *nbt.NBT, dataVersion int) (ps *paletteSection, err error) {
+	ps = &paletteSection{dataVersion: dataVersion, paletteIndx: make(map[string]int, 0), blocks: make([]int, 4096)}
+
+	dataPalette, okay := NBTChild(section, "Palette").Data.([]nbt.NBT)
+	if !okay {
+		err = fmt.Errorf("a Palette that is not a List")
+		return
+	}
+
+	for indx := range dataPalette {
+		var s MCBlockState
+		s, err = blockStateFromNBT(&dataPalette[indx])
+		if err != nil {
+			return
+		}
+		ps.palette = append(ps.palette, s)
+		ps.paletteIndx[s.String()] = indx
+	}
+
+	if len(ps.palette) == 0 {
+		err = fmt.Errorf("empty Palette")
+		return
+	}
+
+	dataBlockStates := NBTChild(section, "BlockStates")
+	if dataBlockStates == nil {
+		// every block is the one block-state in the Palette
+		return
+	}
+
+	longs, okay := dataBlockStates.Data.([]int64)
+	if !okay {
+		err = fmt.Errorf("BlockStates that are not a Long_Array")
+		return
+	}
+	bits := paletteBits(len(ps.palette))
+	if len(longs) != packedLength(bits, dataVersion) {
+		err = fmt.Errorf("%d longs of BlockStates for a Palette of %d, rather than %d", len(longs), len(ps.palette), packedLength(bits, dataVersion))
+		return
+	}
+
+	mask := uint64(1 << uint(bits)) - 1
+	for indx := 0; indx < 4096; indx++ {
+		indxLong, shift := packedPosition(indx, bits, dataVersion)
+		valu := uint64(longs[indxLong]) >> uint(shift)
+		if shift + bits > 64 {
+			valu |= uint64(longs[indxLong + 1]) << uint(64 - shift)
+		}
+		ps.blocks[indx] = int(valu & mask)
+
+		if ps.blocks[indx] >= len(ps.palette) {
+			err = fmt.Errorf("block %d has Palette index %d, beyond the Palette of %d", indx, ps.blocks[indx], len(ps.palette))
+			return
+		}
+	}
+
+	return
+}
+
+// indexes are packed in as few bits as will hold the largest index, but never fewer than 4
+//
+func paletteBits(size int) (bits int) {
+	bits = 4
+	for (1 << uint(bits)) < size {
+		bits++
+	}
+
+	return
+}
+
+// before 20w17a, indexes were packed end to end, with some spanning two longs;  since then, a long holds as many whole
+// indexes as will fit, and any bits left over go unused
+//
+func packedLength(bits int, dataVersion int) int {
+	if dataVersion < dataVersionTightPacking {
+		return (4096 * bits + 63) / 64
+	}
+
+	perLong := 64 / bits
+	return (4096 + perLong - 1) / perLong
+}
+
+func packedPosition(indx int, bits int, dataVersion int) (indxLong int, shift int) {
+	if dataVersion < dataVersionTightPacking {
+		indxLong = (indx * bits) / 64
+		shift = (indx * bits) % 64
+		return
+	}
+
+	perLong := 64 / bits
+	indxLong = indx / perLong
+	shift = (indx % perLong) * bits
+
+	return
+}
+
+func (ps *paletteSection) getBlock(indxBlock int) (id uint16, data uint8, err error) {
+	id, data, err = BlockStateLegacy(ps.getState(indxBlock), ps.dataVersion)
+
+	return
+}
+
+func (ps *paletteSection) setBlock(indxBlock int, id uint16, data uint8) (err error) {
+	err = ps.setState(indxBlock, LegacyBlockState(id, data, ps.dataVersion))
+
+	return
+}
+
+func (ps *paletteSection) getState(indxBlock int) (s MCBlockState) {
+	s = ps.palette[ps.blocks[indxBlock]]
+
+	return
+}
+
+func (ps *paletteSection) setState(indxBlock int, s MCBlockState) (err error) {
+	indx, okay := ps.paletteIndx[s.String()]
+	if !okay {
+		indx = len(ps.palette)
+		ps.palette = append(ps.palette, s)
+		ps.paletteIndx[s.String()] = indx
+	}
+
+	ps.blocks[indxBlock] = indx
+	ps.dirty = true
+
+	return
+}
+
+// the Palette is rebuilt on the way out, with just the block-states still in use, in the order they were first listed,
+// so that it does not grow with every edit
+//
+func (ps *paletteSection) flush(section *nbt.NBT) {
+	if !ps.dirty {
+		return
+	}
+
+	used := make([]bool, len(ps.palette))
+	for _, indx := range ps.blocks {
+		used[indx] = true
+	}
+
+	remap := make([]int, len(ps.palette))
+	palette := make([]MCBlockState, 0)
+	for indx, s := range ps.palette {
+		if used[indx] {
+			remap[indx] = len(palette)
+			palette = append(palette, s)
+		}
+	}
+
+	ps.palette = palette
+	ps.paletteIndx = make(map[string]int, len(palette))
+	for indx, s := range palette {
+		ps.paletteIndx[s.String()] = indx
+	}
+	for indx := range ps.blocks {
+		ps.blocks[indx] = remap[ps.blocks[indx]]
+	}
+
+	bits := paletteBits(len(palette))
+	longs := make([]int64, packedLength(bits, ps.dataVersion))
+	for indx, valu := range ps.blocks {
+		indxLong, shift := packedPosition(indx, bits, ps.dataVersion)
+		longs[indxLong] = int64(uint64(longs[indxLong]) | (uint64(valu) << uint(shift)))
+		if shift + bits > 64 {
+			longs[indxLong + 1] = int64(uint64(longs[indxLong + 1]) | (uint64(valu) >> uint(64 - shift)))
+		}
+	}
+
+	nbtPalette := make([]nbt.NBT, 0, len(palette))
+	for _, s := range palette {
+		nbtPalette = append(nbtPalette, s.toNBT())
+	}
+
+	setChild(section, nbt.NBT{nbt.TAG_List, nbt.TAG_Compound, "Palette", uint32(len(nbtPalette)), nbtPalette})
+	setChild(section, nbt.NBT{nbt.TAG_Long_Array, 0, "BlockStates", uint32(len(longs)), longs})
+
+	ps.dirty = false
+}
+
+// a Palette entry is a compound of the block's Name and, if it has any, a compound of its Properties, all as strings
+//
+func blockStateFromNBT(elem *nbt.NBT) (s MCBlockState, err error) {
+	nbtName := NBTChild(elem, "Name")
+	if nbtName == nil {
+		err = fmt.Errorf("Palette entry without a Name")
+		return
+	}
+	s.Name = nbtName.Data.(string)
+
+	if nbtProps := NBTChild(elem, "Properties"); nbtProps != nil {
+		s.Properties = make(map[string]string, 0)
+		for _, prop := range nbtProps.Data.([]nbt.NBT) {
+			if prop.Type == nbt.TAG_String {
+				s.Properties[prop.Name] = prop.Data.(string)
+			}
+		}
+	}
+
+	return
+}
+
+func (s MCBlockState) toNBT() (rslt nbt.NBT) {
+	elems := []nbt.NBT{nbt.NBT{nbt.TAG_String, 0, "Name", uint32(len(s.Name)), s.Name}}
+
+	if len(s.Properties) > 0 {
+		props := make([]nbt.NBT, 0, len(s.Properties))
+		for _, key := range sortedKeys(s.Properties) {
+			props = append(props, nbt.NBT{nbt.TAG_String, 0, key, uint32(len(s.Properties[key])), s.Properties[key]})
+		}
+		elems = append(elems, nbt.NBT{nbt.TAG_Compound, 0, "Properties", uint32(len(props)), props})
+	}
+
+	rslt = nbt.NBT{nbt.TAG_Compound, 0, "LISTELEM", uint32(len(elems)), elems}
+
+	return
+}
+
+// setChild replaces the element of an NBT compound with the same name as elem, or adds elem if there is none
+//
+func setChild(n *nbt.NBT, elem nbt.NBT) {
+	if child := NBTChild(n, elem.Name); child != nil {
+		*child = elem
+		return
+	}
+
+	n.Data = append(n.Data.([]nbt.NBT), elem)
+	n.Size = uint32(len(n.Data.([]nbt.NBT)))
+}
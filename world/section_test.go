@@ -0,0 +1,109 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/landru27/nbt"
+)
+
+// before 2529 (20w17a), indexes are packed end to end, some spanning two longs; from 2529 on, a long holds only whole
+// indexes, and the bits left over at its top go unused
+//
+func TestPackedLayout(t *testing.T) {
+	tests := []struct {
+		bits        int
+		dataVersion int
+		length      int
+	}{
+		{4, 1631, 256},
+		{5, 1631, 320},
+		{7, 1631, 448},
+		{4, 2566, 256},
+		{5, 2566, 342},
+		{7, 2566, 456},
+	}
+
+	for _, tt := range tests {
+		if n := packedLength(tt.bits, tt.dataVersion); n != tt.length {
+			t.Errorf("packedLength(%d, %d) = %d, want %d", tt.bits, tt.dataVersion, n, tt.length)
+		}
+	}
+
+	positions := []struct {
+		indx        int
+		bits        int
+		dataVersion int
+		indxLong    int
+		shift       int
+	}{
+		{12, 5, 1631, 0, 60},
+		{13, 5, 1631, 1, 1},
+		{4095, 5, 1631, 319, 59},
+		{12, 5, 2566, 1, 0},
+		{11, 5, 2566, 0, 55},
+		{4095, 5, 2566, 341, 15},
+		{9, 7, 2566, 1, 0},
+	}
+
+	for _, tt := range positions {
+		if indxLong, shift := packedPosition(tt.indx, tt.bits, tt.dataVersion); indxLong != tt.indxLong || shift != tt.shift {
+			t.Errorf("packedPosition(%d, %d, %d) = %d, %d, want %d, %d", tt.indx, tt.bits, tt.dataVersion, indxLong, shift, tt.indxLong, tt.shift)
+		}
+	}
+}
+
+// a Section written out by flush reads back in as the same blocks, at either packing, and at sizes of Palette that pack
+// indexes into 4, 5 and 7 bits
+//
+func TestPaletteSectionRoundTrip(t *testing.T) {
+	for _, dataVersion := range []int{1631, 2566} {
+		for _, size := range []int{2, 17, 100} {
+			air := MCBlockState{Name: "minecraft:air"}
+			section := &nbt.NBT{nbt.TAG_Compound, 0, "LISTELEM", 1, []nbt.NBT{
+				nbt.NBT{nbt.TAG_List, nbt.TAG_Compound, "Palette", 1, []nbt.NBT{air.toNBT()}},
+			}}
+
+			ps, err := newPaletteSection(section, dataVersion)
+			if err != nil {
+				t.Fatalf("newPaletteSection : %s", err)
+			}
+
+			// every block-state used somewhere, in no order that would happen to line up with the longs
+			want := make([]string, 4096)
+			for indx := range want {
+				n := (indx * 7) % size
+				s := MCBlockState{Name: fmt.Sprintf("minecraft:block_%d", n), Properties: map[string]string{"n": fmt.Sprint(n % 3)}}
+				want[indx] = s.String()
+
+				if err = ps.setState(indx, s); err != nil {
+					t.Fatalf("setState : %s", err)
+				}
+			}
+			ps.flush(section)
+
+			bits := paletteBits(len(ps.palette))
+			if len(ps.palette) != size {
+				t.Fatalf("DataVersion %d : Palette of %d, want %d", dataVersion, len(ps.palette), size)
+			}
+			if longs := NBTChild(section, "BlockStates").Data.([]int64); len(longs) != packedLength(bits, dataVersion) {
+				t.Errorf("DataVersion %d, Palette of %d : %d longs of BlockStates, want %d", dataVersion, len(ps.palette), len(longs), packedLength(bits, dataVersion))
+			}
+
+			reread, err := newPaletteSection(section, dataVersion)
+			if err != nil {
+				t.Fatalf("DataVersion %d, Palette of %d : newPaletteSection : %s", dataVersion, len(ps.palette), err)
+			}
+
+			for indx := range want {
+				if s := reread.getState(indx); s.String() != want[indx] {
+					t.Errorf("DataVersion %d, Palette of %d : block %d is %s, want %s", dataVersion, len(ps.palette), indx, s, want[indx])
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+// +build !windows
+
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"os"
+	"syscall"
+)
+
+// Minecraft holds a lock on session.lock for as long as it has the world open; Java's file locks are POSIX record locks,
+// so we ask whether any other process holds one, without taking one ourselves
+//
+// (versions of Minecraft before 1.16 only write a timestamp into session.lock, without locking it; for those, there is no
+// sure way to tell from outside the game, and this reports that the world is not in use;  see sessionStampLive for how
+// those are told apart instead)
+//
+func sessionLocked(filename string) (locked bool, err error) {
+	fh, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err != nil {
+		return
+	}
+	defer fh.Close()
+
+	lock := syscall.Flock_t{Type: syscall.F_WRLCK, Whence: 0, Start: 0, Len: 0}
+	err = syscall.FcntlFlock(fh.Fd(), syscall.F_GETLK, &lock)
+	if err != nil {
+		return
+	}
+
+	locked = lock.Type != syscall.F_UNLCK
+
+	return
+}
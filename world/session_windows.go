@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// on Windows, Java's file locks are mandatory, so while Minecraft holds session.lock, we cannot even read it
+//
+func sessionLocked(filename string) (locked bool, err error) {
+	_, err = ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return true, nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,2115 @@
+// Package world reads and edits Minecraft worlds, in the Anvil region file format : blocks, blockentities and entities
+// are edited by world-coordinates, and the regions holding them are loaded as needed and saved back out when done
+//
+//     w, err := world.Open("saves/Hesperia/region")
+//     ...
+//     err = w.EditBlock(4, 59, 173, 1, 0)
+//     ...
+//     err = w.SaveAllEdits()
+//
+// every edit can be journaled, so that it can be undone (see MCJournal), or planned, so that it can be reviewed without
+// being made (see MCEditPlan)
+//
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/landru27/nbt"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  declare our internal datatypes and their interfaces  //////////////////////////////////////////////////////////////////////
+
+type MCWorld struct {
+	FlagDebug bool
+	FlagJSOND bool
+	FlagXAirBlocks bool
+	FlagSkipEntities bool
+	FlagSkipBlockEntities bool
+	FlagResetBlockEntities bool
+	FlagClaimSession bool
+	FlagHoldEdits bool
+	PathWorld string
+	PathSave  string
+	Dimension string
+	Level     *MCLevel
+	Regions   map[[2]int]*MCRegion
+	CacheSize int
+	Journal   *MCJournal
+	Plan      *MCEditPlan
+	Compression byte
+	Jobs        int
+	Stats       MCStats
+	Log         io.Writer
+	Light       map[uint16]MCBlockLight
+
+	workers     chan struct{}
+	workersInit sync.Once
+	mutex       sync.Mutex
+
+	regionClock uint64
+	saveBegun   bool
+	chunkStates map[[2]int]chunkEditState
+	savedFiles  map[string]bool
+
+	relightChunks map[[2]int]bool
+}
+
+// counts of what a world has had done to it, for reporting once the work is done
+//
+type MCStats struct {
+	BlockEdits              int
+	BlockEditsSkipped       int
+	EntityEdits             int
+	EntityEditsSkipped      int
+	BlockEntityEdits        int
+	BlockEntityEditsSkipped int
+	ChunksDecoded           int
+	ChunksPassedThrough     int
+	ChunksRelit             int
+	BiomeEdits              int
+	BiomeEditsSkipped       int
+}
+
+// Open readies the world whose save folder, or whose directory of region files, is at path; regions are not read until
+// they are needed, so this only checks that the directory is there;  the settings returned are the defaults, which can be
+// changed before any editing begins
+//
+func Open(path string) (w *MCWorld, err error) {
+	return OpenDimension(path, "")
+}
+
+// what we know about a chunk's edits beyond its chunkdata; this is kept aside when a region is evicted, so that it is
+// still known if the region is loaded again
+//
+type chunkEditState struct {
+	ResetBENeeded bool
+}
+
+func (w *MCWorld) EditBlock(x int, y int, z int, id uint16, data uint8) (err error) {
+	err = w.editBlock(x, y, z, id, data, nil)
+
+	return
+}
+
+// EditBlockState places a block given by its block-state (e.g., 'minecraft:oak_stairs[facing=east,half=bottom]'), rather
+// than by its id and data value; into a chunk stored in the legacy format, the block-state is translated into the id and
+// data value that it came from
+//
+func (w *MCWorld) EditBlockState(x int, y int, z int, s MCBlockState) (err error) {
+	err = w.editBlock(x, y, z, 0, 0, &s)
+
+	return
+}
+
+func (w *MCWorld) editBlock(x int, y int, z int, id uint16, data uint8, state *MCBlockState) (err error) {
+	// a chunk has Sections for y of 0 to 255 only; below or above that, there is nowhere to put the block
+	if y < 0 || y > 255 {
+		err = fmt.Errorf("y %d is outside 0..255", y)
+		return
+	}
+
+	air := (id == 0)
+	if state != nil {
+		air = state.isAir()
+	}
+
+	// this flag causes 'air' blocks ('.' blueprint glyph) to be treated like 'null' blocks ('X' blueprint glyph);
+	// this is useful when redo'ing a blueprint after fixing the blocks on the blueprint; assuming the blueprint
+	// is reasonably complete to begin with, things that one has added to the gameworld will tend to be in the
+	// 'empty' spaces -- i.e., where there is only air;  with this flag, redo'ing a blueprint will preserve those
+	// sorts of in-game edits;  crops that have been planted will be preserved, too, if those planting areas do
+	// not have planted-crops defined on the blueprint
+	//
+	// this is not a 100% solution of course; places where the ground has been trimmed away, structures that have
+	// been tweaked, etc. will be reset according to the blueprint; but it should work well as a 96% solution
+	//
+	if w.FlagXAirBlocks {
+		if air {
+			w.Stats.BlockEditsSkipped++
+			return
+		}
+	}
+
+	rgn, err := w.LoadRegion(x, z)
+	if err != nil {
+		return
+	}
+	rgn.Dirty = true
+	rx := rgn.RX
+	rz := rgn.RZ
+
+	// calculate the in-region chunk coordinates and chunkdata index
+	cx := int(math.Floor(float64(x) / 16.0))
+	cy := int(                   y  / 16   )
+	cz := int(math.Floor(float64(z) / 16.0))
+	indxChunk := ((cz - (rz * 32)) * 32) + (cx - (rx * 32))
+
+	err = w.DecodeChunk(&rgn.Chunks[indxChunk])
+	if err != nil {
+		return
+	}
+	chnk := &rgn.Chunks[indxChunk]
+
+	// calculate the in-chunk block coordinates and blockdata index
+	ix := x - (cx * 16)
+	iy := y       % 16
+	iz := z - (cz * 16)
+	indxBlock := (iy * 256) + (iz * 16) + ix
+
+	// empty Sections of a chunk are not stored in the region file, but we might want to build into them anyway;
+	// thus, if a Section is not in the current data, we first add it as a Section filled with air; we also
+	// add any empty sections between this one and the first existing one below this one;  in theory, Minecraft
+	// supports missing Sections inbetween existing Sections, but Minecraft itself seems to define them anyway
+	// when the occassion arises
+	//
+	for indx := 0; indx <= cy; indx++ {
+		var sect sectionCodec
+		sect, err = chnk.sectionAt(indx)
+		if err != nil {
+			return
+		}
+		if sect != nil {
+			continue
+		}
+
+		// a Section that was there already, with only light in it, stays when this is undone
+		if chnk.sectionNBT(indx) == nil && w.Journal != nil {
+			w.Journal.Record(MCJournalRecord{Kind: "section", X: cx * 16, Y: indx * 16, Z: cz * 16})
+		}
+
+		if w.Plan != nil {
+			w.Plan.Record(newMCEditPlanEntry("section", cx * 16, indx * 16, cz * 16))
+		}
+
+		chnk.addSection(indx)
+	}
+
+	// fetch the Section we need to update; return early if it does not exist
+	sect, err := chnk.sectionAt(cy)
+	if err != nil {
+		return
+	}
+
+	if sect == nil {
+		w.Stats.BlockEditsSkipped++
+		return
+	}
+
+	// since 1.13, a chunk has several Heightmaps, packed like BlockStates, for different purposes; rather than
+	// work each of those out, we leave them out, and Minecraft works them out as it loads the chunk, as it does
+	// for any Heightmaps missing from a chunk;  they are journaled before the block, so that undoing puts them
+	// back after the block
+	//
+	if dataHeightmaps := chnk.ChunkDataRefs["Heightmaps"]; dataHeightmaps != nil && len(dataHeightmaps.Data.([]nbt.NBT)) > 0 {
+		if w.Journal != nil {
+			err = w.journalHeightmaps(x, z, dataHeightmaps)
+			if err != nil {
+				return
+			}
+		}
+
+		dataHeightmaps.Size = 0
+		dataHeightmaps.Data = []nbt.NBT{}
+	}
+
+	// record what is here now, so that this edit can be undone; any Sections we just added were recorded above, so
+	// that undoing puts this block back before taking its Section away
+	if w.Journal != nil {
+		err = w.journalBlock(x, y, z, chnk, sect, indxBlock)
+		if err != nil {
+			return
+		}
+	}
+
+	if w.Plan != nil {
+		var errOld error
+		entry := newMCEditPlanEntry("block", x, y, z)
+		entry.OldID, entry.OldData, errOld = sect.getBlock(indxBlock)
+		if errOld != nil {
+			entry.OldState = sect.getState(indxBlock).String()
+		}
+		entry.NewID, entry.NewData = id, data
+		if state != nil {
+			entry.NewState = state.String()
+		}
+		w.Plan.Record(entry)
+	}
+
+	// the Section codec takes care of how the block is actually stored (see section.go)
+	if state != nil {
+		err = sect.setState(indxBlock, *state)
+	} else {
+		err = sect.setBlock(indxBlock, id, data)
+	}
+	if err != nil {
+		return
+	}
+
+	// a door or double plant, placed by id and data, is only whole once its other half is known (see pairHalves)
+	if state == nil && chnk.DataVersion >= dataVersionFlattening {
+		err = w.pairHalves(chnk, x, y, z)
+		if err != nil {
+			return
+		}
+	}
+
+	// the HeightMap figures heavily into light-level calculations; rather than keep it up to date block by block,
+	// we note which columns have edits, and work out their HeightMap afresh once the edits are done (see
+	// recomputeHeightMaps)
+	//
+	// (since 1.13, a chunk has instead several Heightmaps; see above)
+	//
+	chnk.EditColumns[((z - (cz * 16)) * 16) + (x - (cx * 16))] = true
+
+	// set this to zero, to instruct Minecraft to recalculate lighting for this chunk; we then work out the light
+	// ourselves before saving (see relight), rather than leave it to chance
+	//
+	if dataLightPopulated := chnk.lightPopulated(); dataLightPopulated != nil {
+		dataLightPopulated.Data = byte(0)
+	}
+	w.markRelight(cx, cz)
+
+	w.Stats.BlockEdits++
+
+	return
+}
+
+// the two halves of a door, or of a double plant, were each only half described by their id and data :  the lower half of a
+// door has the way it faces and whether it is open, and the upper half the side of its hinge and whether it is powered, and
+// the upper half of a double plant does not say what kind of plant it is;  so, as Minecraft's own conversion does, once both
+// halves are in place, each takes what it is missing from the other
+//
+// this is done as either half is placed, so that it does not matter which comes first;  the half that was already there is
+// journaled before it changes, just as the half being placed was
+//
+func (w *MCWorld) pairHalves(chnk *MCChunk, x int, y int, z int) (err error) {
+	cx := int(math.Floor(float64(x) / 16.0))
+	cz := int(math.Floor(float64(z) / 16.0))
+	indxColumn := ((z - (cz * 16)) * 16) + (x - (cx * 16))
+
+	stateAt := func(yy int) (sect sectionCodec, indxBlock int, s MCBlockState, err error) {
+		if yy < 0 || yy > 255 {
+			return
+		}
+		sect, err = chnk.sectionAt(yy / 16)
+		if err != nil || sect == nil {
+			return
+		}
+		indxBlock = ((yy % 16) * 256) + indxColumn
+		s = sect.getState(indxBlock)
+		return
+	}
+
+	_, _, s, err := stateAt(y)
+	if err != nil {
+		return
+	}
+
+	yLower := y
+	switch s.Properties["half"] {
+	case "lower":
+	case "upper":
+		yLower = y - 1
+	default:
+		return
+	}
+
+	sectLower, indxLower, lower, err := stateAt(yLower)
+	if err != nil || sectLower == nil {
+		return
+	}
+	sectUpper, indxUpper, upper, err := stateAt(yLower + 1)
+	if err != nil || sectUpper == nil {
+		return
+	}
+	if lower.Properties["half"] != "lower" || upper.Properties["half"] != "upper" {
+		return
+	}
+
+	var pairedLower, pairedUpper MCBlockState
+	switch {
+	case strings.HasSuffix(lower.Name, "_door") && upper.Name == lower.Name:
+		pairedLower = withProperties(lower, "hinge", upper.Properties["hinge"], "powered", upper.Properties["powered"])
+		pairedUpper = withProperties(upper, "facing", lower.Properties["facing"], "open", lower.Properties["open"])
+
+	case isDoublePlant(lower.Name) && isDoublePlant(upper.Name):
+		pairedLower = lower
+		pairedUpper = withProperties(upper)
+		pairedUpper.Name = lower.Name
+
+	default:
+		return
+	}
+
+	halves := []struct {
+		y      int
+		sect   sectionCodec
+		indx   int
+		was    MCBlockState
+		paired MCBlockState
+	}{
+		{yLower, sectLower, indxLower, lower, pairedLower},
+		{yLower + 1, sectUpper, indxUpper, upper, pairedUpper},
+	}
+	for _, half := range halves {
+		if half.paired.String() == half.was.String() {
+			continue
+		}
+
+		if w.Journal != nil && half.y != y {
+			err = w.journalBlock(x, half.y, z, chnk, half.sect, half.indx)
+			if err != nil {
+				return
+			}
+		}
+
+		err = half.sect.setState(half.indx, half.paired)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// the HeightMap of a chunk holds, for each column, the height just above its highest block that blocks any light (see
+// light.go);  Minecraft lights a column from the sky straight down to there, so a HeightMap left too high leaves 'phantom'
+// blocks obstructing the light from the sky, and one left too low lets the sky shine through a roof
+//
+// recomputeHeightMaps works out the HeightMap afresh for every column with block edits, by scanning its Sections from the
+// top down;  it is done as part of saving, when the edits to a chunk are done
+//
+func (w *MCWorld) recomputeHeightMaps() (err error) {
+	for _, rgn := range w.Regions {
+		for indx := range rgn.Chunks {
+			chnk := &rgn.Chunks[indx]
+			if !chnk.Decoded || len(chnk.EditColumns) == 0 {
+				continue
+			}
+
+			// chunks since 1.13 have no HeightMap of this kind; see EditBlock
+			if dataHeightMap := chnk.ChunkDataRefs["HeightMap"]; dataHeightMap != nil {
+				for indxColumn := range chnk.EditColumns {
+					var height int32
+					height, err = w.columnHeight(chnk, indxColumn)
+					if err != nil {
+						return
+					}
+					dataHeightMap.Data.([]int32)[indxColumn] = height
+				}
+			}
+
+			chnk.EditColumns = make(map[int]bool, 0)
+		}
+	}
+
+	return
+}
+
+func (w *MCWorld) columnHeight(chnk *MCChunk, indxColumn int) (height int32, err error) {
+	for cy := 15; cy >= 0; cy-- {
+		var sect sectionCodec
+		sect, err = chnk.sectionAt(cy)
+		if err != nil {
+			return
+		}
+		if sect == nil {
+			continue
+		}
+
+		for iy := 15; iy >= 0; iy-- {
+			id, _, errBlock := sect.getBlock((iy * 256) + indxColumn)
+
+			// a block-state with no legacy id is taken to be solid, as it is for lighting
+			if errBlock != nil || w.blockLight(id).Opacity > 0 {
+				height = int32((cy * 16) + iy + 1)
+				return
+			}
+		}
+	}
+
+	return
+}
+
+func (w *MCWorld) EditEntity(x int, y int, z int, nbtentity *nbt.NBT) (err error) {
+	if y < 0 || y > 255 {
+		err = fmt.Errorf("y %d is outside 0..255", y)
+		return
+	}
+
+	// this flag causes all entity edits to be skipped; this is useful when redo'ing a blueprint after
+	// fixing the blocks on the blueprint; blocks always replace themselves, but entities are always
+	// new, so skipping entities can prevent ending up with too much livestock roaming around, superimposed
+	// armorstands, and duplicate named entities such as dogs and cats, which are intended (by naming them)
+	// to be unique
+	//
+	// blocks always replace themselves because the data structures holding blocks are of fixed size and
+	// have positional implications, whereas entities are stored in an open-ended list of elements and have
+	// their position encoded as explicit properties of those elements
+	//
+	if w.FlagSkipEntities {
+		w.Stats.EntityEditsSkipped++
+		return
+	}
+
+	rgn, err := w.LoadRegion(x, z)
+	if err != nil {
+		return
+	}
+	rgn.Dirty = true
+	rx := rgn.RX
+	rz := rgn.RZ
+
+	// calculate the in-region chunk coordinates and chunkdata index
+	cx := int(math.Floor(float64(x) / 16.0))
+	cz := int(math.Floor(float64(z) / 16.0))
+	indxChunk := ((cz - (rz * 32)) * 32) + (cx - (rx * 32))
+
+	err = w.DecodeChunk(&rgn.Chunks[indxChunk])
+	if err != nil {
+		return
+	}
+
+	err = checkChunkEntities(rgn.Chunks[indxChunk].DataVersion)
+	if err != nil {
+		return
+	}
+
+	// fetch references to the data structures we need to update; return early if they do not exist
+	dataEntities := rgn.Chunks[indxChunk].ChunkDataRefs["Entities"]
+
+	if dataEntities == nil {
+		w.Stats.EntityEditsSkipped++
+		return
+	}
+
+	// modify the entity to give it a position in the Minecraft world
+	nbtentity.Data.([]nbt.NBT)[3].Data.([]nbt.NBT)[0].Data = float64(x)
+	nbtentity.Data.([]nbt.NBT)[3].Data.([]nbt.NBT)[1].Data = float64(y)
+	nbtentity.Data.([]nbt.NBT)[3].Data.([]nbt.NBT)[2].Data = float64(z)
+
+	// ensure that it is marked as a LISTELEM
+	nbtentity.Name = "LISTELEM"
+
+	// entities are described as they were before 1.16; a chunk of 1.16 or later has the UUID in its own form, which is
+	// also the one undo goes looking for
+	uuidMost, uuidLeast, uuidOkay := entityUUID(nbtentity)
+	if uuidOkay {
+		setEntityUUID(nbtentity, uuidMost, uuidLeast, rgn.Chunks[indxChunk].DataVersion)
+	}
+
+	//debug
+	//fmt.Fprintf(w.Log, "EditEntity : %v\n", nbtentity)
+
+	// add the entity to the collection of entities in this chunk's chunkdata
+	//
+	// the first line setting the List value to TAG_Compound is really only necessary for the
+	// 1st entity, since before that (when the 'Entities' list is empty), it is TAG_End, but
+	// just setting it each time is less work (fewer opcodes) than testing the current value,
+	// even though it seems pointless to us pesky humans in our concrete, analog existence
+	//
+	dataEntities.List = nbt.TAG_Compound
+	dataEntities.Size++
+	dataEntities.Data = append(dataEntities.Data.([]nbt.NBT), *nbtentity)
+
+	if w.Plan != nil {
+		entry := newMCEditPlanEntry("entity", x, y, z)
+		if nbtA := NBTChild(nbtentity, "id"); nbtA != nil {
+			entry.Name = nbtA.Data.(string)
+		}
+		w.Plan.Record(entry)
+	}
+
+	// entities are new, rather than replacing anything, so undoing this edit is a matter of finding this one again
+	if w.Journal != nil && uuidOkay {
+		w.Journal.Record(MCJournalRecord{Kind: "entity", X: x, Y: y, Z: z, UUIDMost: uuidMost, UUIDLeast: uuidLeast})
+	}
+
+	w.Stats.EntityEdits++
+
+	return
+}
+
+// an entity's UUID is kept as two Longs, UUIDMost and UUIDLeast, or, since 1.16 (in 20w12a), as an array of 4 Ints, UUID,
+// the most significant first;  Minecraft reads only the form of its own version, and ignores the other
+//
+const dataVersionIntArrayUUID = 2514
+
+func entityUUID(data *nbt.NBT) (most int64, least int64, okay bool) {
+	if nbtUUID := NBTChild(data, "UUID"); nbtUUID != nil {
+		ints, okayInts := nbtUUID.Data.([]int32)
+		if !okayInts || len(ints) != 4 {
+			return
+		}
+		most = int64((uint64(uint32(ints[0])) << 32) | uint64(uint32(ints[1])))
+		least = int64((uint64(uint32(ints[2])) << 32) | uint64(uint32(ints[3])))
+		return most, least, true
+	}
+
+	nbtMost := NBTChild(data, "UUIDMost")
+	nbtLeast := NBTChild(data, "UUIDLeast")
+	if nbtMost == nil || nbtLeast == nil {
+		return
+	}
+	most, okayMost := nbtMost.Data.(int64)
+	least, okayLeast := nbtLeast.Data.(int64)
+	okay = okayMost && okayLeast
+
+	return
+}
+
+// checkChunkEntities refuses a chunk whose entities are not kept in the chunk itself (see MCFormatProfile), so that they
+// are not silently left out of an edit or a capture
+//
+func checkChunkEntities(dataVersion int) (err error) {
+	p, err := FormatProfile(dataVersion)
+	if err != nil {
+		return
+	}
+
+	if !p.ChunkEntities {
+		err = fmt.Errorf("a Minecraft %s world keeps its entities in entities/r.X.Z.mca, which worldcraft is unable to read or write; use -skipentities to leave them out", p.Name)
+	}
+
+	return
+}
+
+// setEntityUUID writes an entity's UUID in the form that a chunk of the given DataVersion keeps it, taking out the other
+// form, should the entity have it;  an entity with its UUID in that form already is left as it is
+//
+func setEntityUUID(data *nbt.NBT, most int64, least int64, dataVersion int) {
+	if (NBTChild(data, "UUID") != nil) == (dataVersion >= dataVersionIntArrayUUID) {
+		return
+	}
+
+	elems := make([]nbt.NBT, 0, len(data.Data.([]nbt.NBT)))
+	for _, elem := range data.Data.([]nbt.NBT) {
+		if elem.Name != "UUID" && elem.Name != "UUIDMost" && elem.Name != "UUIDLeast" {
+			elems = append(elems, elem)
+		}
+	}
+
+	if dataVersion >= dataVersionIntArrayUUID {
+		ints := []int32{int32(uint64(most) >> 32), int32(most), int32(uint64(least) >> 32), int32(least)}
+		elems = append(elems, nbt.NBT{nbt.TAG_Int_Array, 0, "UUID", 4, ints})
+	} else {
+		elems = append(elems, nbt.NBT{nbt.TAG_Long, 0, "UUIDMost", 0, most})
+		elems = append(elems, nbt.NBT{nbt.TAG_Long, 0, "UUIDLeast", 0, least})
+	}
+
+	data.Data = elems
+	data.Size = uint32(len(elems))
+}
+
+func (w *MCWorld) EditBlockEntity(x int, y int, z int, nbtentity *nbt.NBT) (err error) {
+	if y < 0 || y > 255 {
+		err = fmt.Errorf("y %d is outside 0..255", y)
+		return
+	}
+
+	// this flag causes all blockentity edits to be skipped; this is useful when redo'ing a blueprint after
+	// fixing the blocks on the blueprint; blocks always replace themselves, but blockentities are always
+	// new, and this evidently causes a serious problem for Minecraft, as it typically crashes when
+	// loading a region with duplicate blockentities
+	//
+	// presumably, the in-game-memory representation of, say, a chest's inventory cannot cope with more
+	// than one item stack assigned to the same inventory slot, or something like that
+	//
+	// blocks always replace themselves because the data structures holding blocks are of fixed size and
+	// have positional implications, whereas blockentities are stored in an open-ended list of elements
+	// and have their position encoded as explicit properties of those elements
+	//
+	if w.FlagSkipBlockEntities {
+		w.Stats.BlockEntityEditsSkipped++
+		return
+	}
+
+	rgn, err := w.LoadRegion(x, z)
+	if err != nil {
+		return
+	}
+	rgn.Dirty = true
+	rx := rgn.RX
+	rz := rgn.RZ
+
+	// calculate the in-region chunk coordinates and chunkdata index
+	cx := int(math.Floor(float64(x) / 16.0))
+	cz := int(math.Floor(float64(z) / 16.0))
+	indxChunk := ((cz - (rz * 32)) * 32) + (cx - (rx * 32))
+
+	err = w.DecodeChunk(&rgn.Chunks[indxChunk])
+	if err != nil {
+		return
+	}
+
+	dataBlockEntities := rgn.Chunks[indxChunk].ChunkDataRefs["TileEntities"]
+
+	if dataBlockEntities == nil {
+		w.Stats.BlockEntityEditsSkipped++
+		return
+	}
+
+	// this flag resets the blockentities in the current chunk; this is useful when redo'ing a blueprint
+	// after fixing the blocks on the blueprint; blocks always replace themselves, but blockentities are
+	// always new, and this evidently causes a serious problem for Minecraft, as it typically crashes when
+	// loading a region with duplicate blockentities
+	//
+	// we use a property on the chunk itself to avoid resetting the blockentities more than once (which
+	// would of course lead to a blockentities list one item in length)
+	//
+	if w.FlagResetBlockEntities {
+		if rgn.Chunks[indxChunk].ResetBENeeded {
+			if w.Journal != nil {
+				err = w.journalBlockEntities("chunkentities", x, y, z, dataBlockEntities)
+				if err != nil {
+					return
+				}
+			}
+
+			dataBlockEntities.Size = 0
+			dataBlockEntities.Data = make([]nbt.NBT, 0)
+
+			rgn.Chunks[indxChunk].ResetBENeeded = false
+		}
+	}
+
+	// record any blockentities already here, so that this edit can be undone
+	if w.Journal != nil {
+		err = w.journalBlockEntities("blockentities", x, y, z, dataBlockEntities)
+		if err != nil {
+			return
+		}
+	}
+
+	if w.Plan != nil {
+		var existing *nbt.NBT
+		existing, err = w.GetBlockEntity(x, y, z)
+		if err != nil {
+			return
+		}
+
+		entry := newMCEditPlanEntry("blockentity", x, y, z)
+		entry.Name = nbtentity.Data.([]nbt.NBT)[0].Data.(string)
+		entry.Duplicate = (existing != nil)
+		w.Plan.Record(entry)
+	}
+
+	// modify the blockentity to give it a position in the Minecraft world
+	nbtentity.Data.([]nbt.NBT)[1].Data = int32(x)
+	nbtentity.Data.([]nbt.NBT)[2].Data = int32(y)
+	nbtentity.Data.([]nbt.NBT)[3].Data = int32(z)
+
+	// ensure that it is marked as a LISTELEM
+	nbtentity.Name = "LISTELEM"
+
+	//debug
+	//fmt.Fprintf(w.Log, "EditBlockEntity : %v\n", nbtentity)
+
+	// add the blockentity to the collection of blockentities in this chunk's chunkdata
+	//
+	// the first line setting the List value to TAG_Compound is really only necessary for the
+	// 1st entity, since before that (when the 'TileEntities' list is empty), it is TAG_End, but
+	// just setting it each time is less work (fewer opcodes) than testing the current value,
+	// even though it seems pointless to us pesky humans in our concrete, analog existence
+	//
+	dataBlockEntities.List = nbt.TAG_Compound
+	dataBlockEntities.Size++
+	dataBlockEntities.Data = append(dataBlockEntities.Data.([]nbt.NBT), *nbtentity)
+
+	w.Stats.BlockEntityEdits++
+
+	return
+}
+
+// the Get* functions are the read-side counterparts of the Edit* functions; they locate data by world-coordinates in
+// the same way, using the same region-loading and chunkdata-reference machinery, but leave the chunkdata untouched
+//
+// a Section that is not stored in the region file is all air, as far as Minecraft is concerned, so we report it as such
+//
+func (w *MCWorld) GetBlock(x int, y int, z int) (id uint16, data uint8, err error) {
+	id = 0
+	data = 0
+
+	sect, indxBlock, err := w.blockSection(x, y, z)
+	if err != nil || sect == nil {
+		return
+	}
+
+	id, data, err = sect.getBlock(indxBlock)
+
+	return
+}
+
+// GetBlockState is GetBlock by block-state; for a chunk stored in the legacy format, the block-state is translated from the
+// block's id and data value
+//
+func (w *MCWorld) GetBlockState(x int, y int, z int) (s MCBlockState, err error) {
+	s = MCBlockState{Name: "minecraft:air"}
+
+	sect, indxBlock, err := w.blockSection(x, y, z)
+	if err != nil || sect == nil {
+		return
+	}
+
+	s = sect.getState(indxBlock)
+
+	return
+}
+
+func (w *MCWorld) blockSection(x int, y int, z int) (sect sectionCodec, indxBlock int, err error) {
+	if y < 0 || y > 255 {
+		return
+	}
+
+	rgn, err := w.LoadRegion(x, z)
+	if err != nil {
+		return
+	}
+	rx := rgn.RX
+	rz := rgn.RZ
+
+	// calculate the in-region chunk coordinates and chunkdata index
+	cx := int(math.Floor(float64(x) / 16.0))
+	cy := int(                   y  / 16   )
+	cz := int(math.Floor(float64(z) / 16.0))
+	indxChunk := ((cz - (rz * 32)) * 32) + (cx - (rx * 32))
+
+	err = w.DecodeChunk(&rgn.Chunks[indxChunk])
+	if err != nil {
+		return
+	}
+
+	// calculate the in-chunk block coordinates and blockdata index
+	ix := x - (cx * 16)
+	iy := y       % 16
+	iz := z - (cz * 16)
+	indxBlock = (iy * 256) + (iz * 16) + ix
+
+	// an absent chunk or Section is all air
+	sect, err = rgn.Chunks[indxChunk].sectionAt(cy)
+
+	return
+}
+
+// GetBlockEntity returns a reference to the blockentity at the given world-coordinates, or nil if there is none; the
+// reference is into the live chunkdata, so callers that want to keep a copy should DeepCopy it
+//
+// unlike EditBlockEntity, we cannot rely on array indexes here, since blockentities that Minecraft has saved back to file
+// come in no particular order; so we search each blockentity for its x, y, z properties by name
+//
+func (w *MCWorld) GetBlockEntity(x int, y int, z int) (rslt *nbt.NBT, err error) {
+	rslt = nil
+
+	rgn, err := w.LoadRegion(x, z)
+	if err != nil {
+		return
+	}
+	rx := rgn.RX
+	rz := rgn.RZ
+
+	// calculate the in-region chunk coordinates and chunkdata index
+	cx := int(math.Floor(float64(x) / 16.0))
+	cz := int(math.Floor(float64(z) / 16.0))
+	indxChunk := ((cz - (rz * 32)) * 32) + (cx - (rx * 32))
+
+	err = w.DecodeChunk(&rgn.Chunks[indxChunk])
+	if err != nil {
+		return
+	}
+
+	dataBlockEntities := rgn.Chunks[indxChunk].ChunkDataRefs["TileEntities"]
+
+	if dataBlockEntities == nil {
+		return
+	}
+
+	for indx := range dataBlockEntities.Data.([]nbt.NBT) {
+		elem := &dataBlockEntities.Data.([]nbt.NBT)[indx]
+
+		bx := NBTChild(elem, "x")
+		by := NBTChild(elem, "y")
+		bz := NBTChild(elem, "z")
+		if bx == nil || by == nil || bz == nil {
+			continue
+		}
+
+		// a blockentity whose coordinates are not Ints is not one we know how to place, so it is passed over
+		vx, okayX := bx.Data.(int32)
+		vy, okayY := by.Data.(int32)
+		vz, okayZ := bz.Data.(int32)
+		if !okayX || !okayY || !okayZ {
+			continue
+		}
+
+		if vx == int32(x) && vy == int32(y) && vz == int32(z) {
+			rslt = elem
+			return
+		}
+	}
+
+	return
+}
+
+// GetEntitiesIn returns references to all of the entities whose position lies within the given box; as with
+// GetBlockEntity, the references are into the live chunkdata
+//
+// entities are stored with the chunk they were in when last saved, and their positions are fractional, so we scan every
+// chunk that overlaps the box, and test each entity's position against the box
+//
+func (w *MCWorld) GetEntitiesIn(box MCBox) (rslt []*nbt.NBT, err error) {
+	rslt = make([]*nbt.NBT, 0)
+
+	cxmin := int(math.Floor(float64(box.MinX) / 16.0))
+	cxmax := int(math.Floor(float64(box.MaxX) / 16.0))
+	czmin := int(math.Floor(float64(box.MinZ) / 16.0))
+	czmax := int(math.Floor(float64(box.MaxZ) / 16.0))
+
+	for cz := czmin; cz <= czmax; cz++ {
+		for cx := cxmin; cx <= cxmax; cx++ {
+			var rgn *MCRegion
+			rgn, err = w.LoadRegion(cx * 16, cz * 16)
+			if err != nil {
+				return
+			}
+			rx := rgn.RX
+			rz := rgn.RZ
+
+			indxChunk := ((cz - (rz * 32)) * 32) + (cx - (rx * 32))
+
+			err = w.DecodeChunk(&rgn.Chunks[indxChunk])
+			if err != nil {
+				return
+			}
+
+			err = checkChunkEntities(rgn.Chunks[indxChunk].DataVersion)
+			if err != nil {
+				return
+			}
+
+			dataEntities := rgn.Chunks[indxChunk].ChunkDataRefs["Entities"]
+
+			if dataEntities == nil {
+				continue
+			}
+
+			for indx := range dataEntities.Data.([]nbt.NBT) {
+				elem := &dataEntities.Data.([]nbt.NBT)[indx]
+
+				pos := NBTChild(elem, "Pos")
+				if pos == nil || len(pos.Data.([]nbt.NBT)) < 3 {
+					continue
+				}
+
+				px := pos.Data.([]nbt.NBT)[0].Data.(float64)
+				py := pos.Data.([]nbt.NBT)[1].Data.(float64)
+				pz := pos.Data.([]nbt.NBT)[2].Data.(float64)
+
+				if box.Contains(px, py, pz) {
+					rslt = append(rslt, elem)
+				}
+			}
+		}
+	}
+
+	return
+}
+
+func (w *MCWorld) LoadRegion(x int, z int) (rgn *MCRegion, err error) {
+	rgn = nil
+	err = nil
+
+	// blocks are identified in terms of world-coordinates, so we need to first translate from world-coordinates
+	// to region-coordinates; if we've already loaded the indicated region, use it; if not, load it
+
+	// calculate the region-x, region-z where the input world-x, world-z resides
+	rx := int(math.Floor(float64(x) / 512.0))
+	rz := int(math.Floor(float64(z) / 512.0))
+
+	// check to see if the indicated region is already loaded
+	if w.Regions == nil {
+		w.Regions = make(map[[2]int]*MCRegion, 0)
+	}
+
+	w.regionClock++
+	if rgn = w.Regions[[2]int{rx, rz}]; rgn != nil {
+		rgn.LastUsed = w.regionClock
+		return
+	}
+
+	// the indicated region is not yet loaded, so make room for it, and load it into memory
+	err = w.evictRegions(1)
+	if err != nil {
+		return
+	}
+
+	filename := fmt.Sprintf("%s/r.%d.%d.mca", w.PathWorld, rx, rz)
+	fmt.Fprintf(w.Log, "LoadRegion filename is  : %s\n", filename)
+	newrgn, err := w.readRegion(rx, rz)
+	if err != nil {
+		err = fmt.Errorf("unable to open region file [%s] [%s]", filename, err)
+		rgn = nil
+		return
+	}
+
+	rgn = &newrgn
+	rgn.LastUsed = w.regionClock
+	w.Regions[[2]int{rx, rz}] = rgn
+	w.restoreChunkStates(rgn)
+
+	return
+}
+
+// the region cache holds at most w.CacheSize regions (no limit, if 0); this makes room for n more by evicting the regions
+// least recently used -- those without edits first, since they can simply be dropped, and then, if need be, those with
+// edits, which are saved first;  during a dry run, regions with edits are never evicted, since they cannot be saved, and
+// neither are they with FlagHoldEdits, so that nothing is saved before SaveAllEdits, and the cache grows to hold them all
+//
+func (w *MCWorld) evictRegions(n int) (err error) {
+	if w.CacheSize <= 0 {
+		return
+	}
+
+	for len(w.Regions) + n > w.CacheSize {
+		var lru *MCRegion
+		for _, elem := range w.Regions {
+			if elem.Dirty && (w.Plan != nil || w.FlagHoldEdits) {
+				continue
+			}
+			if lru == nil || (!elem.Dirty && lru.Dirty) || (elem.Dirty == lru.Dirty && elem.LastUsed < lru.LastUsed) {
+				lru = elem
+			}
+		}
+		if lru == nil {
+			return
+		}
+
+		err = w.EvictRegion(lru.RX, lru.RZ)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// EvictRegion drops a region from the region cache, saving it first if it has edits; a batch job that knows it is done
+// with a region can use this to free the memory it takes up; any *MCRegion for it must no longer be used
+//
+func (w *MCWorld) EvictRegion(rx int, rz int) (err error) {
+	rgn := w.Regions[[2]int{rx, rz}]
+	if rgn == nil {
+		return
+	}
+
+	if rgn.Dirty {
+		if w.Plan != nil {
+			return fmt.Errorf("region %d, %d has edits, which cannot be saved during a dry run", rx, rz)
+		}
+
+		err = w.beginSave()
+		if err != nil {
+			return
+		}
+
+		err = w.recomputeHeightMaps()
+		if err != nil {
+			return
+		}
+
+		err = w.relight()
+		if err != nil {
+			return
+		}
+
+		err = w.SaveRegion(rx, rz)
+		if err != nil {
+			return
+		}
+	}
+
+	if w.FlagDebug {
+		fmt.Fprintf(w.Log, "EvictRegion region is   : %d, %d\n", rx, rz)
+	}
+
+	if w.chunkStates == nil {
+		w.chunkStates = make(map[[2]int]chunkEditState, 0)
+	}
+	for indx := range rgn.Chunks {
+		chnk := &rgn.Chunks[indx]
+		if chnk.Decoded {
+			w.chunkStates[[2]int{chnk.CX, chnk.CZ}] = chunkEditState{chnk.ResetBENeeded}
+		}
+	}
+
+	delete(w.Regions, [2]int{rx, rz})
+
+	return
+}
+
+func (w *MCWorld) restoreChunkStates(rgn *MCRegion) {
+	for indx := range rgn.Chunks {
+		chnk := &rgn.Chunks[indx]
+		if state, okay := w.chunkStates[[2]int{chnk.CX, chnk.CZ}]; okay {
+			chnk.ResetBENeeded = state.ResetBENeeded
+		}
+	}
+}
+
+// PreloadRegions loads, in parallel, every region overlapping the given world-coordinates that is not already loaded; the
+// regions a blueprint will touch can thus be read all at once, rather than one after another as edits first reach them;
+// regions without a region file are left for LoadRegion to complain about, should an edit actually reach them
+//
+func (w *MCWorld) PreloadRegions(x1 int, z1 int, x2 int, z2 int) {
+	rxmin := int(math.Floor(float64(x1) / 512.0))
+	rzmin := int(math.Floor(float64(z1) / 512.0))
+	rxmax := int(math.Floor(float64(x2) / 512.0))
+	rzmax := int(math.Floor(float64(z2) / 512.0))
+
+	if w.Regions == nil {
+		w.Regions = make(map[[2]int]*MCRegion, 0)
+	}
+
+	coords := make([][2]int, 0)
+	for rz := rzmin; rz <= rzmax; rz++ {
+		for rx := rxmin; rx <= rxmax; rx++ {
+			if w.Regions[[2]int{rx, rz}] == nil {
+				coords = append(coords, [2]int{rx, rz})
+			}
+		}
+	}
+
+	// never preload more than the region cache has room for; the rest are loaded as the render reaches them
+	if w.CacheSize > 0 {
+		room := w.CacheSize - len(w.Regions)
+		if room < 0 {
+			room = 0
+		}
+		if len(coords) > room {
+			coords = coords[:room]
+		}
+	}
+
+	rgns := make([]MCRegion, len(coords))
+	errs := make([]error, len(coords))
+	w.parallel(len(coords), func(indx int) {
+		rgns[indx], errs[indx] = w.readRegion(coords[indx][0], coords[indx][1])
+	})
+
+	// add them in a fixed order, so that everything after this is the same as it would be had they been loaded one by one
+	for indx := range coords {
+		if errs[indx] != nil {
+			continue
+		}
+		fmt.Fprintf(w.Log, "LoadRegion filename is  : %s/r.%d.%d.mca\n", w.PathWorld, coords[indx][0], coords[indx][1])
+		w.regionClock++
+		rgns[indx].LastUsed = w.regionClock
+		w.Regions[coords[indx]] = &rgns[indx]
+		w.restoreChunkStates(&rgns[indx])
+	}
+}
+
+// readRegion reads a region file into a new region object;  region files are stored in the 'world' directory, whose path
+// has been previously set, and are identified by their x, z coordinates
+//
+func (w *MCWorld) readRegion(rx int, rz int) (newrgn MCRegion, err error) {
+	filename := fmt.Sprintf("%s/r.%d.%d.mca", w.PathWorld, rx, rz)
+	bufFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	// instantiate a new region object
+	newrgn = MCRegion{RX: rx, RZ: rz}
+	newrgn.Chunks = make([]MCChunk, 0)
+
+	// slice the filedata to read the header blocks, and store into our 1024-element arrays for holding this information
+	rChunkDataLocations := bytes.NewReader(bufFile[0:4096])
+	err = binary.Read(rChunkDataLocations, binary.BigEndian, &newrgn.ChunkDataLocations)
+	if err != nil {
+		return
+	}
+
+	rChunkTimestamps := bytes.NewReader(bufFile[4096:8192])
+	err = binary.Read(rChunkTimestamps, binary.BigEndian, &newrgn.ChunkTimestamps)
+	if err != nil {
+		return
+	}
+
+	// iterate over the 1024 possible chuncks in this region, looking for chunkdata to read
+	for indx := 0; indx < 1024; indx++ {
+		// calculate the in-region chunk coordinates from the serial chunk index
+		ix := indx % 32
+		iz := int(indx / 32)
+		// and the world-coordinates
+		cx := ix + (rx * 32)
+		cz := iz + (rz * 32)
+
+		// instantiate a new chunk object; we do this even if there will be no data to read, so that we stay in
+		// alignment with the serial chunk index when we later scan through chunks to write out to file
+		newchnk := MCChunk{IX: ix, IZ: iz, CX: cx, CZ: cz, ResetBENeeded: w.FlagResetBlockEntities, EditColumns: make(map[int]bool, 0)}
+
+		// the Minecraft specs don't seem to indicate this, but we deduce that a chunk is only a defined chunk if
+		// it has a non-zero data offset, data-block count, and timestamp
+
+		if newrgn.ChunkDataLocations[indx].getOffsetValue() != 0 {
+			if newrgn.ChunkDataLocations[indx].Count != 0 {
+				if newrgn.ChunkTimestamps[indx] != 0 {
+
+					// calculate filedata locations based on information from the header blocks
+					offset := newrgn.ChunkDataLocations[indx].getOffsetValue()
+
+					offsetbeg := uint32(offset) * 4096
+					if int(offsetbeg + 5) > len(bufFile) {
+						err = fmt.Errorf("chunk %d, %d lies beyond the end of region file [%s]", ix, iz, filename)
+						return
+					}
+					rChunkInfo := bytes.NewReader(bufFile[offsetbeg:offsetbeg + 5])
+
+					// read in 5 bytes (a 4-byte uint32 and a single byte) in order to determine the length
+					// and compression scheme of the chunkdata
+					var length uint32
+					var cmpres byte
+					err = binary.Read(rChunkInfo, binary.BigEndian, &length)
+					if err != nil {
+						return
+					}
+					err = binary.Read(rChunkInfo, binary.BigEndian, &cmpres)
+					if err != nil {
+						return
+					}
+
+					// chunkdata too large for the region file lives in a file of its own, signalled by the high bit
+					// of the compression type; the region file keeps just the length and compression type
+					var bufData []byte
+					if cmpres & ChunkExternal != 0 {
+						cmpres = cmpres &^ ChunkExternal
+						newchnk.External = true
+
+						filemcc := fmt.Sprintf("%s/c.%d.%d.mcc", w.PathWorld, cx, cz)
+						bufData, err = ioutil.ReadFile(filemcc)
+						if err != nil {
+							err = fmt.Errorf("unable to read external chunk file [%s] [%s]", filemcc, err)
+							return
+						}
+					} else {
+						// reslice for the actual worlddata of this chunk; the length includes the compression-type byte;
+						// we go by the length rather than the count of 4KB blocks, which earlier versions of this
+						// utility could get wrong for very large chunks
+						databeg := offsetbeg + 5
+						dataend := offsetbeg + 4 + length
+						if length < 1 || int(dataend) > len(bufFile) {
+							err = fmt.Errorf("chunk %d, %d has a length of %d, which runs past the end of region file [%s]", ix, iz, length, filename)
+							return
+						}
+						bufData = bufFile[databeg:dataend]
+					}
+
+					// keep the chunkdata just as it is in the file; it is only uncompressed and parsed when an edit first
+					// needs it (see DecodeChunk), and chunks that are never needed are written back out as they are
+					newchnk.Length = length
+					newchnk.CompressionType = cmpres
+					newchnk.Raw = bufData
+				}
+			}
+		}
+
+		// add the new chunk (whether we also populated it with data or not) into this region's list of chunks
+		newrgn.Chunks = append(newrgn.Chunks, newchnk)
+	}
+
+	return
+}
+
+// DecodeChunk uncompresses and parses a chunk's chunkdata, the first time it is needed; every function that works with a
+// chunk's data calls this before doing so; chunks without chunkdata are left as they are
+//
+func (w *MCWorld) DecodeChunk(chnk *MCChunk) (err error) {
+	if chnk.Decoded || chnk.Raw == nil {
+		return
+	}
+
+	// uncompress the data so that we can work with it
+	bufTemp, err := decompressChunkData(chnk.CompressionType, chnk.Raw)
+	if err != nil {
+		return fmt.Errorf("unable to uncompress chunk %d, %d : %s", chnk.CX, chnk.CZ, err)
+	}
+
+	// a non-empty debug string is the signal to ReadNBTData to produce verbose output
+	strDebug := ""
+	if w.FlagDebug {
+		strDebug = fmt.Sprintf("chunk %d, %d", chnk.IX, chnk.IZ)
+	}
+
+	// parse the data out of Minecraft's NBT format into data structures we interact with
+	var rdrTemp *bytes.Reader
+	rdrTemp = bytes.NewReader(bufTemp)
+	chnk.ChunkData, err = nbt.ReadNBTData(rdrTemp, nbt.TAG_NULL, strDebug)
+	if err != nil {
+		return fmt.Errorf("unable to parse chunk %d, %d : %s", chnk.CX, chnk.CZ, err)
+	}
+
+	// the DataVersion tells us how the chunk stores its blocks (see section.go and profile.go); chunks from before 1.9
+	// have none;  a chunk in a format we are unable to edit is refused here, before anything goes looking inside it
+	if nbtDataVersion := NBTChild(&chnk.ChunkData, "DataVersion"); nbtDataVersion != nil {
+		valu, okay := nbtDataVersion.Data.(int32)
+		if !okay {
+			return fmt.Errorf("chunk %d, %d has a DataVersion that is not an Int", chnk.CX, chnk.CZ)
+		}
+		chnk.DataVersion = int(valu)
+	}
+	if _, err = FormatProfile(chnk.DataVersion); err != nil {
+		return fmt.Errorf("unable to edit chunk %d, %d : %s", chnk.CX, chnk.CZ, err)
+	}
+	err = chnk.BuildDataRefs()
+	if err != nil {
+		return
+	}
+
+	chnk.Decoded = true
+	chnk.Raw = nil
+	w.Stats.ChunksDecoded++
+
+	return
+}
+
+// the region file format provides for three ways of storing chunkdata : GZip and ZLib compression, and no compression at all;
+// Minecraft itself writes ZLib, but other tools write the others, and Minecraft reads all three
+//
+const (
+	CompressionGZip byte = 1
+	CompressionZLib byte = 2
+	CompressionNone byte = 3
+)
+
+// a chunk whose data will not fit in the 255 4KB blocks that a region file's header can describe is stored in a file of
+// its own, c.X.Z.mcc (X, Z being the chunk's world-coordinates), next to the region file; the region file keeps only the
+// chunk's length (1) and compression type, with this bit set
+//
+const ChunkExternal byte = 0x80
+
+// the most 4KB blocks a chunk can occupy within a region file, the count being a single byte
+//
+const maxChunkBlocks = 255
+
+func decompressChunkData(cmpres byte, data []byte) (rslt []byte, err error) {
+	var rdr io.Reader
+
+	switch cmpres {
+	case CompressionGZip:
+		rdr, err = gzip.NewReader(bytes.NewReader(data))
+	case CompressionZLib:
+		rdr, err = zlib.NewReader(bytes.NewReader(data))
+	case CompressionNone:
+		rslt = data
+		return
+	default:
+		err = fmt.Errorf("unknown compression type %d", cmpres)
+	}
+	if err != nil {
+		return
+	}
+
+	rslt, err = ioutil.ReadAll(rdr)
+
+	return
+}
+
+func compressChunkData(cmpres byte, data []byte) (rslt []byte, err error) {
+	var bufZ bytes.Buffer
+	var wz io.WriteCloser
+
+	switch cmpres {
+	case CompressionGZip:
+		wz = gzip.NewWriter(&bufZ)
+	case CompressionZLib:
+		wz = zlib.NewWriter(&bufZ)
+	case CompressionNone:
+		rslt = data
+		return
+	default:
+		err = fmt.Errorf("unknown compression type %d", cmpres)
+		return
+	}
+
+	_, err = wz.Write(data)
+	if err == nil {
+		err = wz.Close()
+	}
+	rslt = bufZ.Bytes()
+
+	return
+}
+
+// beginSave is done before any region is saved, whether at the end of a run or when a region with edits is evicted
+// from the region cache partway through
+//
+func (w *MCWorld) beginSave() (err error) {
+	// Minecraft keeps its own copy of every loaded region in memory, and writes it back out as it sees fit; if the game
+	// has this world open, our edits would at best be overwritten, and at worst be interleaved with the game's own writes
+	if !w.saveBegun {
+		err = w.checkSession()
+		if err != nil {
+			return
+		}
+	}
+
+	// the journal goes out first, so that even a save that fails partway through can be undone; it is written out
+	// again before each later save, so that it always covers every edit saved so far
+	if w.Journal != nil {
+		err = w.Journal.Write()
+		if err != nil {
+			return
+		}
+		if !w.saveBegun {
+			fmt.Fprintf(w.Log, "journal filename is     : %s\n", w.Journal.Filename)
+		}
+	}
+
+	w.saveBegun = true
+
+	return
+}
+
+func (w *MCWorld) SaveAllEdits() (err error) {
+	err = w.beginSave()
+	if err != nil {
+		return
+	}
+
+	err = w.recomputeHeightMaps()
+	if err != nil {
+		return
+	}
+
+	// relighting can touch chunks in regions without edits of their own, so it comes before finding the regions to save
+	err = w.relight()
+	if err != nil {
+		return
+	}
+
+	// only regions with edits need saving; they are saved in order of their coordinates, so that runs are repeatable
+	keys := make([][2]int, 0)
+	for key, elem := range w.Regions {
+		if elem.Dirty {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][1] != keys[j][1] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+
+	// each region is saved by a goroutine of its own, but the real work of saving -- encoding chunks, and writing files
+	// -- is done by the pool of workers (see parallel), so it is still bounded by -j; with -json, the regions are saved
+	// one after another, so that their chunkdata comes out in order
+	if w.Jobs <= 1 || w.FlagJSOND {
+		for _, key := range keys {
+			err = w.SaveRegion(key[0], key[1])
+			if err != nil {
+				return
+			}
+		}
+
+		return
+	}
+
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	for indx, key := range keys {
+		wg.Add(1)
+		go func(indx int, rx int, rz int) {
+			defer wg.Done()
+			errs[indx] = w.SaveRegion(rx, rz)
+		}(indx, key[0], key[1])
+	}
+	wg.Wait()
+
+	for _, err = range errs {
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// parallel calls fn for each index from 0 to n-1, with no more than w.Jobs calls running at any one time; the workers are
+// shared by everything that calls this, even calls running alongside each other, so -j bounds all of them together;  fn
+// must not itself call parallel, lest the pool be used up by calls waiting on each other
+//
+func (w *MCWorld) parallel(n int, fn func(indx int)) {
+	if w.Jobs <= 1 {
+		for indx := 0; indx < n; indx++ {
+			fn(indx)
+		}
+		return
+	}
+
+	w.workersInit.Do(func() {
+		w.workers = make(chan struct{}, w.Jobs)
+	})
+
+	var wg sync.WaitGroup
+	for indx := 0; indx < n; indx++ {
+		wg.Add(1)
+		go func(indx int) {
+			defer wg.Done()
+			w.workers <- struct{}{}
+			fn(indx)
+			<-w.workers
+		}(indx)
+	}
+	wg.Wait()
+}
+
+// a world's session.lock is in its save folder
+//
+func (w *MCWorld) sessionFilename() (filename string) {
+	pathSave := w.PathSave
+	if pathSave == "" {
+		pathSave = saveFolderOf(w.PathWorld)
+	}
+
+	return filepath.Join(pathSave, "session.lock")
+}
+
+// SessionInUse reports whether Minecraft looks to have this world open, either by the lock that 1.16 and later hold on
+// session.lock, or, before 1.16, by the timestamp in it (see sessionStampLive)
+//
+func (w *MCWorld) SessionInUse() (running bool, err error) {
+	filename := w.sessionFilename()
+	if _, err = os.Stat(filename); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	running, err = sessionLocked(filename)
+	if err != nil || running {
+		return
+	}
+
+	running, err = w.sessionStampLive(filename)
+
+	return
+}
+
+// checkSession is done before anything is saved, and refuses to go on while Minecraft looks to have the world open;  with
+// FlagClaimSession, a game from before 1.16 is instead made to stop saving the world (see claimSession)
+//
+func (w *MCWorld) checkSession() (err error) {
+	filename := w.sessionFilename()
+	if _, err = os.Stat(filename); os.IsNotExist(err) {
+		return nil
+	}
+
+	locked, err := sessionLocked(filename)
+	if err != nil {
+		return
+	}
+	if locked {
+		return fmt.Errorf("the world's session.lock is held; is Minecraft running with this world open?")
+	}
+
+	if w.FlagClaimSession {
+		claimed, err := w.claimSession()
+		if err != nil {
+			return err
+		}
+		if claimed {
+			fmt.Fprintf(w.Log, "session.lock claimed    : a Minecraft from before 1.16 with this world open will stop saving it\n")
+		}
+		return nil
+	}
+
+	live, err := w.sessionStampLive(filename)
+	if err != nil {
+		return
+	}
+	if live {
+		return fmt.Errorf("the world's session.lock and level.dat look to be in use by a Minecraft from before 1.16; quit to the title screen and wait a little, or use -claimsession to make the game stop saving the world")
+	}
+
+	return
+}
+
+// before 1.16, Minecraft did not lock session.lock, but wrote the time at which it opened the world into it, as a Long in
+// milliseconds;  it also writes the time of each save into level.dat, as LastPlayed, both as it autosaves, every 45
+// seconds, and as it closes the world;  so a world opened since it was last saved, or saved within the last couple of
+// autosaves, is taken to be open still
+//
+// a session.lock of 1.16 or later, with no time in it, is left to sessionLocked
+//
+func (w *MCWorld) sessionStampLive(filename string) (live bool, err error) {
+	bufLock, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil || len(bufLock) != 8 {
+		return
+	}
+	opened := int64(binary.BigEndian.Uint64(bufLock))
+
+	// level.dat is read afresh, rather than taken from w.Level, since the game may have saved it since the world was opened
+	var lastPlayed int64
+	filenameLevel := filepath.Join(filepath.Dir(filename), "level.dat")
+	if _, errStat := os.Stat(filenameLevel); errStat == nil {
+		var l *MCLevel
+		l, err = ReadMCLevel(filenameLevel)
+		if err != nil {
+			return
+		}
+		lastPlayed = l.LastPlayed
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	live = opened > lastPlayed || now - lastPlayed < sessionAutosaveWindow
+
+	return
+}
+
+// how long after the game last saved level.dat it might still have the world open; two of its 45-second autosaves
+//
+const sessionAutosaveWindow = int64(90 * 1000)
+
+// claimSession takes session.lock over from a game before 1.16, by writing the time now into it, as the game itself would
+// on opening the world again;  before each save, such a game checks that the time there is still its own, and gives up the
+// save if not, rather than write its own copy of the world over ours, so that anything done in the game since it was last
+// saved is lost;  for that reason, this is only done when asked for, with FlagClaimSession
+//
+// a session.lock of 1.16 or later, with no time in it, is left as it is
+//
+func (w *MCWorld) claimSession() (claimed bool, err error) {
+	filename := w.sessionFilename()
+	bufLock, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil || len(bufLock) != 8 {
+		return
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	if then := int64(binary.BigEndian.Uint64(bufLock)); then >= now {
+		now = then + 1
+	}
+	binary.BigEndian.PutUint64(bufLock, uint64(now))
+
+	err = ioutil.WriteFile(filename, bufLock, 0644)
+	if err != nil {
+		return false, fmt.Errorf("unable to claim [%s] [%s]", filename, err)
+	}
+
+	return true, nil
+}
+
+func (w *MCWorld) SaveRegion(rx, rz int) (err error) {
+	var filename string
+	var rgn *MCRegion
+
+	// we replace the original region file with our edits, but never by writing into it directly; the new region data
+	// goes to a temporary file first, which takes the place of the original only once it is complete (see writeRegionFile)
+	filename = fmt.Sprintf("%s/r.%d.%d.mca", w.PathWorld, rx, rz)
+	fmt.Fprintf(w.Log, "SaveRegion filename is  : %s\n", filename)
+
+	rgn = w.Regions[[2]int{rx, rz}]
+	if rgn == nil {
+		return fmt.Errorf("region %d, %d is not loaded", rx, rz)
+	}
+
+	// begin writing chunkdata at the 3rd 4KB block, in order to skip over the two header blocks
+	totaloffset := 2
+
+	// chunks that are too large for the region file are written to .mcc files of their own, before the region file that
+	// refers to them; .mcc files left over from chunks that have since shrunk are retired once the region file is written
+	var filesmccStale []string
+
+	// gather up chunkdata into a set of buffers; we do this first, because some header information (the number of 4KB
+	// blocks the data occupies) depends on preparing the data, and of course the data might have changed radically
+	// as a result of edits applied
+	var bufChunkDataSet []bytes.Buffer
+	for indx := 0; indx < 1024; indx++ {
+		// sanity check to make sure we are dealing with the correct chunk
+		if rgn.Chunks[indx].IX != indx % 32 {
+			return fmt.Errorf("unexpected IX coordinate; region %d, %d;  indx %d;  chunk %d, %d", rx, rz, indx, rgn.Chunks[indx].IX, rgn.Chunks[indx].IZ)
+		}
+		if rgn.Chunks[indx].IZ != int(indx / 32) {
+			return fmt.Errorf("unexpected IZ coordinate; region %d, %d;  indx %d;  chunk %d, %d", rx, rz, indx, rgn.Chunks[indx].IX, rgn.Chunks[indx].IZ)
+		}
+
+		// unpacked Sections are packed back up into the chunkdata
+		if rgn.Chunks[indx].Decoded {
+			rgn.Chunks[indx].flushSections()
+		}
+
+		// optionally output the chunkdata to JSON, for various sorts of external analysis
+		if w.FlagJSOND == true {
+			err = w.DecodeChunk(&rgn.Chunks[indx])
+			if err != nil {
+				return
+			}
+
+			var bufJSON []byte
+			bufJSON, err = json.MarshalIndent(&rgn.Chunks[indx].ChunkData, "", "  ")
+			if err != nil {
+				return
+			}
+			w.Log.Write(bufJSON)
+		}
+	}
+
+	// chunks keep the compression they were read with, unless we have been asked to use a particular one
+	cmpres := func(indx int) (rslt byte) {
+		rslt = rgn.Chunks[indx].CompressionType
+		if w.Compression != 0 {
+			rslt = w.Compression
+		}
+		return
+	}
+
+	// compress the chunks, in parallel; chunks we have decoded are encoded afresh; chunks we never needed are copied
+	// through just as they were read, unless they need recompressing, which does not require parsing them
+	bufComps := make([][]byte, 1024)
+	passthroughs := make([]bool, 1024)
+	errChunks := make([]error, 1024)
+	w.parallel(1024, func(indx int) {
+		var errChunk error
+		defer func() { errChunks[indx] = errChunk }()
+
+		if rgn.ChunkDataLocations[indx].getOffsetValue() == 0 || rgn.ChunkDataLocations[indx].Count == 0 || rgn.ChunkTimestamps[indx] == 0 {
+			return
+		}
+
+		if rgn.Chunks[indx].Decoded {
+			var bufChunkData bytes.Buffer
+			errChunk = nbt.WriteNBTData(&bufChunkData, &rgn.Chunks[indx].ChunkData)
+			if errChunk != nil {
+				return
+			}
+
+			bufComps[indx], errChunk = compressChunkData(cmpres(indx), bufChunkData.Bytes())
+		} else if cmpres(indx) == rgn.Chunks[indx].CompressionType {
+			bufComps[indx] = rgn.Chunks[indx].Raw
+			passthroughs[indx] = true
+		} else {
+			var bufTemp []byte
+			bufTemp, errChunk = decompressChunkData(rgn.Chunks[indx].CompressionType, rgn.Chunks[indx].Raw)
+			if errChunk != nil {
+				return
+			}
+
+			bufComps[indx], errChunk = compressChunkData(cmpres(indx), bufTemp)
+		}
+	})
+	for indx := 0; indx < 1024; indx++ {
+		if errChunks[indx] != nil {
+			return fmt.Errorf("unable to encode chunk %d, %d of region %d, %d [%s]", rgn.Chunks[indx].CX, rgn.Chunks[indx].CZ, rx, rz, errChunks[indx])
+		}
+	}
+
+	// lay the compressed chunks out in the region file, in order
+	qtyPassedThrough := 0
+	for indx := 0; indx < 1024; indx++ {
+		// instantiate a buffer for the compressed NBT data -- what we want to actually write to file
+		var bufZ bytes.Buffer
+
+		if rgn.ChunkDataLocations[indx].getOffsetValue() != 0 {
+			if rgn.ChunkDataLocations[indx].Count != 0 {
+				if rgn.ChunkTimestamps[indx] != 0 {
+
+					passthrough := passthroughs[indx]
+					if passthrough {
+						qtyPassedThrough++
+					}
+					bufZ.Write(bufComps[indx])
+
+					// chunks we never decoded keep their chunkdata as it now is in the file, in case we save again
+					if !rgn.Chunks[indx].Decoded {
+						rgn.Chunks[indx].Raw = bufComps[indx]
+					}
+
+					// the additional byte in 'leninfo' is to account for the compression-type byte
+					lendata := len(bufZ.Bytes())
+					leninfo := lendata + 1
+					// the length and compression type that we store are within the 1st 4KB block,
+					// so we take them into account when calculating how many 4KB blocks we need
+					lenin4k := int((leninfo + 4) / 4096) + 1
+
+					filemcc := fmt.Sprintf("%s/c.%d.%d.mcc", w.PathWorld, rgn.Chunks[indx].CX, rgn.Chunks[indx].CZ)
+					if lenin4k > maxChunkBlocks {
+						// an external chunk copied through is already in its .mcc file
+						if !(passthrough && rgn.Chunks[indx].External) {
+							fmt.Fprintf(w.Log, "SaveRegion external is  : %s\n", filemcc)
+							err = writeRegionFile(filemcc, bufZ.Bytes(), w.firstSave(filemcc))
+							if err != nil {
+								return
+							}
+						}
+
+						bufZ.Reset()
+						leninfo = 1
+						lenin4k = 1
+						rgn.Chunks[indx].External = true
+					} else if rgn.Chunks[indx].External {
+						filesmccStale = append(filesmccStale, filemcc)
+						rgn.Chunks[indx].External = false
+					}
+
+					rgn.ChunkDataLocations[indx].setOffset(totaloffset)
+					rgn.ChunkDataLocations[indx].Count = uint8(lenin4k)
+
+					rgn.Chunks[indx].Length = uint32(leninfo)
+					rgn.Chunks[indx].CompressionType = cmpres(indx)
+
+					// store the next block(s) of chunkdata after this block / these blocks
+					totaloffset += lenin4k
+				}
+			}
+		}
+
+		// retain the result, even if we skipped writing out any NBT data, to stay in alignment with the 1024-element
+		// loops during the rest of this function
+		bufChunkDataSet = append(bufChunkDataSet, bufZ)
+	}
+
+	// assemble the whole region file in memory; that way, nothing touches the disk until we know we have all of it
+	var fh bytes.Buffer
+
+	// write out the chuckdata location-in-file informtion
+	for indx := 0; indx < 1024; indx++ {
+		err = binary.Write(&fh, binary.BigEndian, rgn.ChunkDataLocations[indx].Offset)
+		if err != nil {
+			return
+		}
+		err = binary.Write(&fh, binary.BigEndian, rgn.ChunkDataLocations[indx].Count)
+		if err != nil {
+			return
+		}
+	}
+
+	// write out the chunk timestamp information
+	for indx := 0; indx < 1024; indx++ {
+		err = binary.Write(&fh, binary.BigEndian, rgn.ChunkTimestamps[indx])
+		if err != nil {
+			return
+		}
+	}
+
+	// write out the chunkdata, gathered up above; pad the chunkdata to the next 4KB boundary, because everything about
+	// region files is in terms of 4KB blocks of filedata
+	for indx := 0; indx < 1024; indx++ {
+		// skip over chunks that are not defined by data
+		if len(bufChunkDataSet[indx].Bytes()) == 0 && !rgn.Chunks[indx].External {
+			continue
+		}
+
+		cmpres := rgn.Chunks[indx].CompressionType
+		if rgn.Chunks[indx].External {
+			cmpres |= ChunkExternal
+		}
+
+		err = binary.Write(&fh, binary.BigEndian, rgn.Chunks[indx].Length)
+		if err != nil {
+			return
+		}
+		err = binary.Write(&fh, binary.BigEndian, cmpres)
+		if err != nil {
+			return
+		}
+
+		lenzpad := (int(rgn.ChunkDataLocations[indx].Count) * 4096) - int(rgn.Chunks[indx].Length + 4)
+		for pad := 0; pad < lenzpad; pad++ {
+			bufChunkDataSet[indx].WriteByte(0)
+		}
+		err = binary.Write(&fh, binary.BigEndian, bufChunkDataSet[indx].Bytes())
+		if err != nil {
+			return
+		}
+	}
+
+	w.mutex.Lock()
+	w.Stats.ChunksPassedThrough += qtyPassedThrough
+	w.mutex.Unlock()
+
+	// writing the region file takes one of the workers, too
+	w.parallel(1, func(indx int) {
+		err = writeRegionFile(filename, fh.Bytes(), w.firstSave(filename))
+	})
+	if err != nil {
+		return
+	}
+	rgn.Dirty = false
+
+	// the stale .mcc files go into the backups, alongside the earlier versions of the region file that used them
+	for _, filemcc := range filesmccStale {
+		if w.firstSave(filemcc) {
+			err = rotateBackups(filemcc)
+			if err != nil {
+				return
+			}
+		}
+		err = os.Remove(filemcc)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// the number of earlier versions of each region file that we keep, as r.X.Z.mca.bak.1 (the most recent) and so on
+//
+const regionBackups = 3
+
+// a region evicted from the region cache might be saved more than once in the same run; only the first save backs up the
+// file, so that the backups are always of earlier runs, and never pushed out by the same run's own intermediate saves
+//
+func (w *MCWorld) firstSave(filename string) (first bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.savedFiles == nil {
+		w.savedFiles = make(map[string]bool, 0)
+	}
+
+	first = !w.savedFiles[filename]
+	w.savedFiles[filename] = true
+
+	return
+}
+
+// writeRegionFile replaces a region file in a way that a crash can never leave it half-written : the new data goes into a
+// temporary file alongside the original, and is synced to disk; the original is rotated into the backups (if asked); and
+// only then does the temporary file take the original's name -- a rename within a directory being all-or-nothing
+//
+func writeRegionFile(filename string, data []byte, backup bool) (err error) {
+	filetemp := filename + ".tmp"
+
+	fh, err := os.OpenFile(filetemp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return
+	}
+
+	_, err = fh.Write(data)
+	if err == nil {
+		err = fh.Sync()
+	}
+	if errClose := fh.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		os.Remove(filetemp)
+		return
+	}
+
+	if backup {
+		err = rotateBackups(filename)
+		if err != nil {
+			return
+		}
+	}
+
+	err = os.Rename(filetemp, filename)
+	if err != nil {
+		return
+	}
+
+	// sync the directory too, so that the rename itself survives a crash
+	if dh, errOpen := os.Open(filepath.Dir(filename)); errOpen == nil {
+		dh.Sync()
+		dh.Close()
+	}
+
+	return
+}
+
+// rotate the backups, oldest first, so that each rename has an empty spot to move into; the original file is copied
+// rather than renamed, so that there is never a moment without one
+//
+func rotateBackups(filename string) (err error) {
+	for indx := regionBackups - 1; indx >= 1; indx-- {
+		older := fmt.Sprintf("%s.bak.%d", filename, indx)
+		if _, errStat := os.Stat(older); errStat == nil {
+			err = os.Rename(older, fmt.Sprintf("%s.bak.%d", filename, indx + 1))
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	if bufOrig, errRead := ioutil.ReadFile(filename); errRead == nil {
+		err = ioutil.WriteFile(filename + ".bak.1", bufOrig, 0644)
+	}
+
+	return
+}
+
+type MCRegion struct {
+	RX                 int
+	RZ                 int
+	Dirty              bool
+	LastUsed           uint64
+	ChunkDataLocations [1024]MCChunkdatalocation
+	ChunkTimestamps    [1024]int32
+	Chunks             []MCChunk
+}
+
+// MCChunkdatalocation
+//
+// a chunkdata descriptor indicates where within the region file the chuck data is found;  the offset is the (0-indexed)
+// index of the first 4KB block holding the chunk data; the count is the number of 4KB blocks used for this chunk;  the offset
+// does not ignore the two header blocks, so the lowest offset for chunk data will be "2", i.e. starting at byte 8192 in the
+// region file (i.e., the 8,193rd byte, the start of the 3rd 4KB block)
+//
+type MCChunkdatalocation struct {
+	Offset [3]byte
+	Count  uint8
+}
+
+// these interface methods are principally for dealing with the odd choice to store the offset as a 24-bit number  (this
+// choice is made even more odd by the fact that the 'count', taking up the 4th byte of what could be a conventional 32-bit
+// number, is completely redundant, since the first piece of chunkdata is the length of the data)
+//
+func (cdl *MCChunkdatalocation) getOffsetValue() (rtrn int) {
+	rtrn = (int(cdl.Offset[2]) << 0) | (int(cdl.Offset[1]) << 8) | (int(cdl.Offset[0]) << 16)
+
+	return
+}
+
+func (cdl *MCChunkdatalocation) setOffset(value int) {
+	cdl.Offset[0] = byte(value >> 16)
+	cdl.Offset[1] = byte(value >>  8)
+	cdl.Offset[2] = byte(value >>  0)
+}
+
+// MCChunk
+//
+type MCChunk struct {
+	IX              int
+	IZ              int
+	CX              int
+	CZ              int
+	Length          uint32
+	CompressionType byte
+	External        bool
+	Raw             []byte
+	Decoded         bool
+	ChunkData       nbt.NBT
+	ChunkDataRefs   map[string]*nbt.NBT
+	ResetBENeeded   bool
+	EditColumns     map[int]bool
+	DataVersion     int
+
+	sections map[int]sectionCodec
+}
+
+// this builds a map of data objects for this chunk's chunkdata;  the chunkdata is in an unordered hierarchy, making it
+// cumbersome to go looking for a given data object every time, so we build up a mapping using a pathed name as the key
+//
+func (c *MCChunk) BuildDataRefs() (err error) {
+	if c.ChunkDataRefs != nil {
+		return fmt.Errorf("BuildDataRefs called again for the same chunk [%d, %d]", c.CX, c.CZ)
+	}
+
+	refLevl := NBTChild(&c.ChunkData, "Level")
+	if refLevl == nil || refLevl.Type != nbt.TAG_Compound {
+		return fmt.Errorf("chunk %d, %d has no Level compound", c.CX, c.CZ)
+	}
+
+	c.ChunkDataRefs = make(map[string]*nbt.NBT, 0)
+	c.ChunkDataRefs["Level"] = refLevl
+
+	// Sections are found by their Y, rather than by reference; see sectionNBT
+	for indxA, elemLevl := range refLevl.Data.([]nbt.NBT) {
+		c.ChunkDataRefs[elemLevl.Name] = &refLevl.Data.([]nbt.NBT)[indxA]
+	}
+
+	return
+}
+
+// LightPopulated tells Minecraft that a chunk's light has been worked out; since 1.14, isLightOn does the same
+//
+func (c *MCChunk) lightPopulated() (rslt *nbt.NBT) {
+	rslt = c.ChunkDataRefs["LightPopulated"]
+	if rslt == nil {
+		rslt = c.ChunkDataRefs["isLightOn"]
+	}
+
+	return
+}
+
+// NBTChild finds an element of an NBT compound by name; we need this wherever we deal with NBT data that Minecraft has
+// written, since there is no guarantee about the order of its elements
+//
+func NBTChild(n *nbt.NBT, name string) (rslt *nbt.NBT) {
+	rslt = nil
+
+	if n == nil || n.Type != nbt.TAG_Compound {
+		return
+	}
+
+	for indx, elem := range n.Data.([]nbt.NBT) {
+		if elem.Name == name {
+			rslt = &n.Data.([]nbt.NBT)[indx]
+			return
+		}
+	}
+
+	return
+}
+
+// MCBox
+//
+// a box is a volume of world-coordinates, inclusive of both corners; it can be given any two opposite corners, and
+// normalizes them so that the Min* values are the west, bottom, north corner
+//
+type MCBox struct {
+	MinX int
+	MinY int
+	MinZ int
+	MaxX int
+	MaxY int
+	MaxZ int
+}
+
+func NewMCBox(x1 int, y1 int, z1 int, x2 int, y2 int, z2 int) (box MCBox) {
+	box.MinX, box.MaxX = x1, x2
+	box.MinY, box.MaxY = y1, y2
+	box.MinZ, box.MaxZ = z1, z2
+
+	if x2 < x1 { box.MinX, box.MaxX = x2, x1 }
+	if y2 < y1 { box.MinY, box.MaxY = y2, y1 }
+	if z2 < z1 { box.MinZ, box.MaxZ = z2, z1 }
+
+	return
+}
+
+// entity positions are fractional; an entity standing anywhere on a block's footprint counts as being at that block
+//
+func (b MCBox) Contains(x float64, y float64, z float64) bool {
+	bx := int(math.Floor(x))
+	by := int(math.Floor(y))
+	bz := int(math.Floor(z))
+
+	return bx >= b.MinX && bx <= b.MaxX && by >= b.MinY && by <= b.MaxY && bz >= b.MinZ && bz <= b.MaxZ
+}
+
@@ -0,0 +1,84 @@
+package world
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"testing"
+
+	"github.com/landru27/nbt"
+)
+
+// a chunk of the given DataVersion with no Sections, as addSection expects to find one
+//
+func newTestChunk(dataVersion int) (chnk *MCChunk) {
+	chnk = &MCChunk{DataVersion: dataVersion, EditColumns: make(map[int]bool, 0)}
+	chnk.ChunkDataRefs = map[string]*nbt.NBT{
+		"Sections": &nbt.NBT{nbt.TAG_List, nbt.TAG_End, "Sections", 0, make([]nbt.NBT, 0)},
+	}
+
+	return
+}
+
+// the halves of a door or double plant, placed by id and data in either order, each take what they are missing from the
+// other (see pairHalves)
+//
+func TestPairHalves(t *testing.T) {
+	tests := []struct {
+		name        string
+		lowerID     uint16
+		lowerData   uint8
+		upperID     uint16
+		upperData   uint8
+		upperFirst  bool
+		lower       string
+		upper       string
+	}{
+		{"door", 193, 3, 193, 9, false,
+			"minecraft:spruce_door[facing=north,half=lower,hinge=right,open=false,powered=false]",
+			"minecraft:spruce_door[facing=north,half=upper,hinge=right,open=false,powered=false]"},
+		{"door, upper half first", 64, 6, 64, 10, true,
+			"minecraft:oak_door[facing=west,half=lower,hinge=left,open=true,powered=true]",
+			"minecraft:oak_door[facing=west,half=upper,hinge=left,open=true,powered=true]"},
+		{"doors of different woods", 64, 3, 193, 8, false,
+			"minecraft:oak_door[facing=north,half=lower,hinge=left,open=false,powered=false]",
+			"minecraft:spruce_door[facing=east,half=upper,hinge=left,open=false,powered=false]"},
+		{"lilac", 175, 1, 175, 8, false, "minecraft:lilac[half=lower]", "minecraft:lilac[half=upper]"},
+		{"rose bush, upper half first", 175, 4, 175, 10, true, "minecraft:rose_bush[half=lower]", "minecraft:rose_bush[half=upper]"},
+		{"sunflower", 175, 0, 175, 11, false, "minecraft:sunflower[half=lower]", "minecraft:sunflower[half=upper]"},
+	}
+
+	for _, tt := range tests {
+		w := &MCWorld{}
+		chnk := newTestChunk(1631)
+		chnk.addSection(0)
+		sect, err := chnk.sectionAt(0)
+		if err != nil || sect == nil {
+			t.Fatalf("%s : no Section [%v]", tt.name, err)
+		}
+
+		// the block at 3, 4, 5 and the one above it
+		place := func(y int, id uint16, data uint8) {
+			if err := sect.setBlock((y * 256) + (5 * 16) + 3, id, data); err != nil {
+				t.Fatalf("%s : setBlock : %s", tt.name, err)
+			}
+			if err := w.pairHalves(chnk, 3, y, 5); err != nil {
+				t.Fatalf("%s : pairHalves : %s", tt.name, err)
+			}
+		}
+		if tt.upperFirst {
+			place(5, tt.upperID, tt.upperData)
+			place(4, tt.lowerID, tt.lowerData)
+		} else {
+			place(4, tt.lowerID, tt.lowerData)
+			place(5, tt.upperID, tt.upperData)
+		}
+
+		if s := sect.getState((4 * 256) + (5 * 16) + 3); s.String() != tt.lower {
+			t.Errorf("%s : lower half is %s, want %s", tt.name, s, tt.lower)
+		}
+		if s := sect.getState((5 * 256) + (5 * 16) + 3); s.String() != tt.upper {
+			t.Errorf("%s : upper half is %s, want %s", tt.name, s, tt.upper)
+		}
+	}
+}
@@ -4,21 +4,23 @@ package main
 //  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
 
 import (
-	"bufio"
 	"crypto/rand"
 	"encoding/binary"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
-	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/landru27/nbt"
+	"github.com/landru27/worldcraft/blueprint"
+	"github.com/landru27/worldcraft/world"
 )
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -26,23 +28,18 @@ import (
 //
 var timeExec time.Time
 
-var world MCWorld
+var gameworld *world.MCWorld
 
 var glyphs []Glyph
 var glyphTags []GlyphTag
 var glyphIndx map[string]int
 var glyphTagIndx map[string]int
+var glyphBlockIndx map[GlyphKey]int
+var glyphItemIndx map[string]int
 
 var entityAtoms []Atom
 var entityAtomIndx map[string]int
 
-var qtyBlockEdits int
-var qtyBlockEditsSkipped int
-var qtyEntityEdits int
-var qtyEntityEditsSkipped int
-var qtyBlockEntityEdits int
-var qtyBlockEntityEditsSkipped int
-
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // main execution point
 //
@@ -57,48 +54,129 @@ func main() {
 	glyphTags = make([]GlyphTag, 0)
 	glyphIndx = make(map[string]int, 0)
 	glyphTagIndx = make(map[string]int, 0)
+	glyphBlockIndx = make(map[GlyphKey]int, 0)
+	glyphItemIndx = make(map[string]int, 0)
 
 	entityAtoms = make([]Atom, 0)
 	entityAtomIndx = make(map[string]int, 0)
 
-	qtyBlockEdits = 0
-	qtyBlockEditsSkipped = 0
-	qtyEntityEdits = 0
-	qtyEntityEditsSkipped = 0
-	qtyBlockEntityEdits = 0
-	qtyBlockEntityEditsSkipped = 0
+	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// the first argument may name a command; without one, we render a blueprint into the world, as we always have
+	command := "render"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command = args[0]
+		args = args[1:]
+	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	// define the available command-line arguments
 	flagDebug := flag.Bool("debug", false, "a flag to enable verbose output, for bug diagnosis and to validate detailed functionality")
 	flagJSOND := flag.Bool("json", false, "a flag to enable dumping the chunkdata to JSON")
-	pathWorld := flag.String("world", "UNDEFINED", "a directory containing a collection of Minecraft region files")
+	flagCompression := flag.String("compression", "preserve", "how to compress the chunks of each region saved : 'preserve' (as each was), 'zlib', 'gzip', or 'none'")
+	flagCache := flag.Int("cache", 16, "the most regions without edits to keep in memory at once; 0 for no limit")
+	flagJobs := flag.Int("j", runtime.NumCPU(), "the number of workers to load, compress and save regions with")
+	flagDryRun := flag.Bool("dryrun", false, "a flag to report what a render would change, without saving any changes")
+	flagClaimSession := flag.Bool("claimsession", false, "a flag to save even while a Minecraft from before 1.16 looks to have the world open, making the game stop saving it; anything done in the game since its last save is lost")
+	pathWorld := flag.String("world", "UNDEFINED", "a Minecraft save folder, or a directory containing a collection of Minecraft region files")
+	flagDimension := flag.String("dimension", "", "for a save folder : the dimension to edit : 'overworld' (the default), 'nether', or 'end'")
 	fileBPrnt := flag.String("blueprint", "UNDEFINED", "a file containing a blueprint of edits to make to the specified Minecraft world")
-	anchorX := flag.Int("X", 0, "the westernmost  coordinate where the blueprint will be rendered in the gameworld")
-	anchorY := flag.Int("Y", 0, "the lowest-layer coordinate where the blueprint will be rendered in the gameworld")
-	anchorZ := flag.Int("Z", 0, "the northernmost coordinate where the blueprint will be rendered in the gameworld")
+	var anchorX, anchorY, anchorZ anchorCoord
+	flag.Var(&anchorX, "X", "the westernmost  coordinate where the blueprint will be rendered in the gameworld; or 'spawn'")
+	flag.Var(&anchorY, "Y", "the lowest-layer coordinate where the blueprint will be rendered in the gameworld; or 'spawn'")
+	flag.Var(&anchorZ, "Z", "the northernmost coordinate where the blueprint will be rendered in the gameworld; or 'spawn'")
+	flagAnchor := flag.String("anchor", "", "a place in the world to anchor the blueprint at, for each of -X, -Y, -Z not given : 'spawn'; or, for render, 'player' or 'player:UUID', to build ahead of where the player stood")
+	flagOffset := flag.String("offset", "1,0,0", "for -anchor player : how many blocks ahead of, above, and to the right of the player the blueprint starts")
+	flagOrigin := flag.String("origin", "nw", "for render : the point of the blueprint that lands on -X, -Y, -Z : the 'nw' (the default), 'ne', 'sw' or 'se' corner of its bottom layer, its 'center', or 'glyph:G', where the glyph G is")
+	flagRotate := flag.Int("rotate", 0, "for render : degrees to turn the blueprint clockwise, as seen from above : 0, 90, 180 or 270")
+	flagMirror := flag.String("mirror", "", "for render : 'x' to reverse the blueprint west to east, or 'z' to reverse it north to south, before any -rotate")
+	cornerX := flag.Int("X2", 0, "for capture : the X coordinate of the corner opposite -X, -Y, -Z")
+	cornerY := flag.Int("Y2", 0, "for capture : the Y coordinate of the corner opposite -X, -Y, -Z")
+	cornerZ := flag.Int("Z2", 0, "for capture : the Z coordinate of the corner opposite -X, -Y, -Z")
+	fileJournal := flag.String("journal", "UNDEFINED", "for undo : a journal file written by a previous run, whose edits will be undone")
 	flagXAirBlocks := flag.Bool("xairblocks", false, "a flag to treat 'air' blocks as 'X' glyphs, skipping over them")
-	flagSkipEntities := flag.Bool("skipentities", false, "a flag to suppress the inclusion of entities shown on a blueprint")
+	flagSkipEntities := flag.Bool("skipentities", false, "a flag to suppress the inclusion of entities shown on a blueprint, or found in a captured box")
 	flagSkipBlockEntities := flag.Bool("skipblockentities", false, "a flag to suppress the inclusion of blockentities shown on the blueprint")
 	flagResetBlockEntities := flag.Bool("resetblockentities", false, "a flag to reset each affected chunk's blockentities prior to adding any from the blueprint")
-	flag.Parse()
+	flagBiome := flag.String("biome", "", "for biome : the biome to set, by name (e.g., 'desert') or by number")
+	flagTag := flag.String("tag", "UNDEFINED", "for give : the glyph-tag, defined in the -blueprint file, whose items to give")
+	flagPlayer := flag.String("player", "", "for give : the UUID of the player to give items to; without one, the single-player world's player")
+	flagEnderChest := flag.Bool("enderchest", false, "for give : a flag to give the items into the player's ender chest, rather than their inventory")
+	flag.CommandLine.Parse(args)
+
+	// undo puts back Sections, entities and blockentities directly, rather than by the Edit* functions that add to the
+	// plan, so a plan of it would leave those out; and it would otherwise save its changes, dry run or not
+	if command == "undo" && *flagDryRun {
+		fmt.Printf("-dryrun is not supported for undo\n")
+		os.Exit(3)
+	}
+
+	// -anchor names the place for whichever of the anchor coordinates were not given on their own;  anchored at the
+	// player, though, the blueprint is placed as a whole, once we know its footprint and which way the player faced
+	anchorPlayer := ""
+	anchoredAtPlayer := *flagAnchor == "player" || strings.HasPrefix(*flagAnchor, "player:")
+	if anchoredAtPlayer {
+		anchorPlayer = strings.TrimPrefix(strings.TrimPrefix(*flagAnchor, "player"), ":")
+
+		if command != "render" {
+			fmt.Printf("-anchor player is only for render\n")
+			os.Exit(3)
+		}
+		given := false
+		flag.Visit(func(f *flag.Flag) { given = given || f.Name == "X" || f.Name == "Y" || f.Name == "Z" || f.Name == "origin" })
+		if given {
+			fmt.Printf("-anchor player places the blueprint itself; use -offset, rather than -X, -Y, -Z or -origin, to move it\n")
+			os.Exit(3)
+		}
+	} else if *flagAnchor != "" {
+		given := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+		for name, coord := range map[string]*anchorCoord{"X": &anchorX, "Y": &anchorY, "Z": &anchorZ} {
+			if given[name] { continue }
+
+			err = coord.Set(*flagAnchor)
+			if err != nil {
+				fmt.Printf("unknown anchor [%s]; expected 'spawn', 'player' or 'player:UUID'\n", *flagAnchor)
+				os.Exit(3)
+			}
+		}
+	}
 
 	// report to the user what values will be used
-	fmt.Printf("output flags    : debug:%t  JSON:%t\n", *flagDebug, *flagJSOND)
+	fmt.Printf("command         : %s\n", command)
+	fmt.Printf("output flags    : debug:%t  JSON:%t  DryRun:%t\n", *flagDebug, *flagJSOND, *flagDryRun)
 	fmt.Printf("action flags    : XAirBlocks:%t  SkipEntities:%t  SkipBlockEntities:%t  ResetBlockEntities:%t\n", *flagXAirBlocks, *flagSkipEntities, *flagSkipBlockEntities, *flagResetBlockEntities)
 	fmt.Printf("world directory : %s\n", *pathWorld)
+	fmt.Printf("dimension       : %s\n", *flagDimension)
 	fmt.Printf("blueprint file  : %s\n", *fileBPrnt)
-	fmt.Printf("build starts at : %d, %d, %d\n", *anchorX, *anchorY, *anchorZ)
+	if anchoredAtPlayer {
+		fmt.Printf("build starts at : %s, offset %s\n", *flagAnchor, *flagOffset)
+	} else if *flagOrigin != "nw" {
+		fmt.Printf("origin          : %s, at %s, %s, %s\n", *flagOrigin, anchorX.String(), anchorY.String(), anchorZ.String())
+	} else {
+		fmt.Printf("build starts at : %s, %s, %s\n", anchorX.String(), anchorY.String(), anchorZ.String())
+	}
+	if *flagRotate != 0 || *flagMirror != "" {
+		fmt.Printf("orientation     : rotate:%d  mirror:%s\n", *flagRotate, *flagMirror)
+	}
+	fmt.Printf("workers         : %d\n", *flagJobs)
 	fmt.Printf("\n")
 
-	// the world object is at the root of the Minecraft data, and so is our interface to that data
-	world = MCWorld{FlagDebug: *flagDebug,
-			FlagJSOND: *flagJSOND,
-			FlagXAirBlocks: *flagXAirBlocks,
-			FlagSkipEntities: *flagSkipEntities,
-			FlagSkipBlockEntities: *flagSkipBlockEntities,
-			FlagResetBlockEntities: *flagResetBlockEntities,
-			PathWorld: *pathWorld}
+	var compression byte
+	switch *flagCompression {
+	case "preserve":
+		compression = 0
+	case "gzip":
+		compression = world.CompressionGZip
+	case "zlib":
+		compression = world.CompressionZLib
+	case "none":
+		compression = world.CompressionNone
+	default:
+		fmt.Printf("unknown compression [%s]\n", *flagCompression)
+		os.Exit(3)
+	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	// read in the definitions of Glyphs, so that the associated blueprint symbols can be interpretted as the Minecraft
@@ -114,11 +192,31 @@ func main() {
 	err = json.Unmarshal(bufJson, &mapJson)
 	panicOnErr(err)
 
-	// assign the data to an array, and build some maps for refering into that array by symbol, and by name
+	// assign the data to an array, and build some maps for refering into that array by symbol, and by name; and, for
+	// going the other way (from the world to a blueprint), by block id and data, and by item id and damage value;  where
+	// more than one glyph shares an id and data, the first one in the legend wins
 	glyphs = mapJson["Glyphs"]
 	for indx, elem := range glyphs {
 		glyphIndx[elem.Glyph] = indx
 		glyphIndx[elem.Name] = indx
+
+		if _, okay := orientSchemes[elem.Orient]; elem.Orient != "" && !okay {
+			fmt.Printf("unknown orient [%s] for glyph [%s] in [%s]\n", elem.Orient, elem.Glyph, fileGlyphs)
+			os.Exit(3)
+		}
+
+		if elem.Type == "block" && elem.Name != "null" && elem.Name != "marker" {
+			if _, okay := glyphBlockIndx[GlyphKey{elem.ID, elem.Data}]; !okay {
+				glyphBlockIndx[GlyphKey{elem.ID, elem.Data}] = indx
+			}
+		}
+
+		if elem.Type == "item" {
+			key := itemKey(elem)
+			if _, okay := glyphItemIndx[key]; !okay {
+				glyphItemIndx[key] = indx
+			}
+		}
 	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -141,287 +239,708 @@ func main() {
 	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	// workhorse variables
-	var linein string
-	var lineglyphtags []string
-	var match bool
-	var matches []string
-
-	var ax, ay, az int
-	var dx, dy, dz int
-	var bx, by, bz int
-	var mx, my, mz int
+	// lint needs only the legend, not a world : it checks each blueprint file named (or the -blueprint file), and exits
+	if command == "lint" {
+		files := flag.Args()
+		if len(files) == 0 {
+			files = []string{*fileBPrnt}
+		}
 
-	// coordinates for where to start building
-	ax = *anchorX
-	ay = *anchorY
-	az = *anchorZ
+		qtyErrors := 0
+		for _, filename := range files {
+			_, diags := lintBlueprint(filename)
+			for _, elem := range diags {
+				fmt.Printf("%s\n", elem)
+				if elem.Severity == blueprint.SeverityError {
+					qtyErrors++
+				}
+			}
+			if len(diags) == 0 {
+				fmt.Printf("%s: no problems found\n", filename)
+			}
+		}
 
-	// coordinates to track offsets as we traverse the blueprint
-	dx = 0
-	dy = 0
-	dz = 0
+		if qtyErrors > 0 {
+			os.Exit(7)
+		}
+		os.Exit(0)
+	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	// read in the blueprint from stdin
-	fh, err := os.Open(*fileBPrnt)
+	// the world object is at the root of the Minecraft data, and so is our interface to that data;  for undo, the journal
+	// knows which world it was made for, so we read it first
+	var journal *world.MCJournal
+	if command == "undo" {
+		journal, err = world.ReadMCJournal(*fileJournal)
+		if err != nil {
+			fmt.Printf("unable to read journal file [%s] [%s]\n", *fileJournal, err)
+			os.Exit(3)
+		}
+
+		if *pathWorld == "UNDEFINED" {
+			*pathWorld = journal.PathWorld
+		}
+	}
+
+	gameworld, err = world.OpenDimension(*pathWorld, *flagDimension)
 	if err != nil {
-		fmt.Printf("unable to open blueprint file [%s] [%s]\n", *fileBPrnt, err)
+		fmt.Printf("%s\n", err)
 		os.Exit(3)
 	}
-	defer fh.Close()
 
-	scanner := bufio.NewScanner(fh)
-	for scanner.Scan() {
-		linein = scanner.Text()
+	// report what the world's level.dat says about it, where it has one
+	if gameworld.Level != nil {
+		fmt.Printf("level name      : %s\n", gameworld.Level.LevelName)
+		fmt.Printf("level version   : %s  DataVersion:%d  version:%d\n", gameworld.Level.VersionName, gameworld.Level.DataVersion, gameworld.Level.Version)
+		fmt.Printf("world spawn     : %d, %d, %d\n", gameworld.Level.SpawnX, gameworld.Level.SpawnY, gameworld.Level.SpawnZ)
+		fmt.Printf("game time       : %d  day time:%d\n", gameworld.Level.Time, gameworld.Level.DayTime)
+		fmt.Printf("world format    : Minecraft %s (%s Sections)\n", gameworld.Level.Format.Name, gameworld.Level.Format.Codec)
+	}
+	fmt.Printf("region directory: %s\n", gameworld.PathWorld)
 
-		// strip off comments, marked by '##'
-		_, linein = regexpReplace(linein, `##.*$`, ``)
+	// anchor coordinates given by name can be found now that the world is open
+	if anchorX.Name == "spawn" || anchorY.Name == "spawn" || anchorZ.Name == "spawn" {
+		if gameworld.Level == nil {
+			fmt.Printf("unable to anchor at the world spawn : no level.dat was found for [%s]\n", *pathWorld)
+			os.Exit(3)
+		}
 
-		// strip off leading and trailing whitespace
-		_, linein = regexpReplace(linein, `^\s+`, ``)
-		_, linein = regexpReplace(linein, `\s+$`, ``)
+		if anchorX.Name == "spawn" { anchorX.Valu = gameworld.Level.SpawnX }
+		if anchorY.Name == "spawn" { anchorY.Valu = gameworld.Level.SpawnY }
+		if anchorZ.Name == "spawn" { anchorZ.Valu = gameworld.Level.SpawnZ }
 
-		// skip any blank lines
-		if match = regexpMatch(linein, `^\s*$`); match { continue }
+		fmt.Printf("build starts at : %d, %d, %d\n", anchorX.Valu, anchorY.Valu, anchorZ.Valu)
+	}
+	fmt.Printf("\n")
+	gameworld.FlagDebug = *flagDebug
+	gameworld.FlagJSOND = *flagJSOND
+	gameworld.FlagXAirBlocks = *flagXAirBlocks
+	gameworld.FlagSkipEntities = *flagSkipEntities
+	gameworld.FlagSkipBlockEntities = *flagSkipBlockEntities
+	gameworld.FlagResetBlockEntities = *flagResetBlockEntities
+	gameworld.FlagClaimSession = *flagClaimSession
+	gameworld.FlagHoldEdits = true
+	gameworld.CacheSize = *flagCache
+	gameworld.Jobs = *flagJobs
+	gameworld.Compression = compression
+	gameworld.Log = os.Stdout
+
+	// blocks the world package does not know the lighting of, such as those of mods, can be given it in the legend
+	for _, elem := range glyphs {
+		if elem.Type == "block" && elem.Light != nil {
+			gameworld.Light[elem.ID] = *elem.Light
+		}
+	}
 
-		// respond to the end-of-layer marker; increment Y-offset and reset Z-offset
-		if match = regexpMatch(linein, `^ *--`); match {
-			dy++
-			dz = 0
-			continue
+	// keep a journal of everything we change, so that a bad render can be undone; or, for a dry run, keep a plan of
+	// everything we would change, so that it can be reviewed before doing it for real;  undo keeps no journal of its
+	// own, and capture changes nothing
+	if command == "render" || command == "biome" {
+		if *flagDryRun {
+			gameworld.Plan = &world.MCEditPlan{}
+		} else {
+			gameworld.Journal = world.NewMCJournal(gameworld.PathWorld, timeExec)
 		}
+	}
 
-		// == defines a glyph-tag
-		if match, matches = regexpParse(linein, `^ *== +([a-z]+) +:((?: +[-A-Za-z]{1,4}:[-_a-z0-9]+){1,9})`); match {
-			var tagname string
-			var eleminfo string
-			var elemname string
-			var elemdata string
-			var indx int
+	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// commands other than 'render' do their work and exit here
+	switch command {
+	case "render":
+		// carry on below
+
+	case "undo":
+		err = gameworld.UndoJournal(journal)
+		if err != nil {
+			fmt.Printf("unable to undo journal [%s] [%s]\n", *fileJournal, err)
+			os.Exit(3)
+		}
 
-			tagname = matches[1]
-			eleminfo = matches[2]
+		err = gameworld.SaveAllEdits()
+		if err != nil {
+			fmt.Printf("unable to save the world [%s] [%s]\n", gameworld.PathWorld, err)
+			os.Exit(3)
+		}
+		fmt.Printf("\n")
+		fmt.Printf("journal records undone     : %d\n", len(journal.Records))
+		fmt.Printf("\n")
+		os.Exit(0)
+
+	case "biome":
+		paintBiome(*flagBiome, anchorX.Valu, anchorZ.Valu, *cornerX, *cornerZ)
+		finishEdits(*flagDryRun)
+
+	case "give":
+		giveGlyphTag(*fileBPrnt, *flagTag, *flagPlayer, *flagEnderChest, *flagDryRun)
+
+	case "capture":
+		box := world.NewMCBox(anchorX.Valu, anchorY.Valu, anchorZ.Valu, *cornerX, *cornerY, *cornerZ)
+		err = captureBlueprint(box, *fileBPrnt)
+		if err != nil {
+			fmt.Printf("unable to capture blueprint [%s] [%s]\n", *fileBPrnt, err)
+			os.Exit(3)
+		}
+		os.Exit(0)
 
-			// if we have not seen this glyphtag before, define it as a glyphtag and in the glyphtag map
-			if _, okay := glyphTagIndx[tagname]; !okay {
-				gt := GlyphTag{tagname, 0, nbt.NBT{nbt.TAG_List, nbt.TAG_Compound, "Items", 0, make([]nbt.NBT, 0)}}
-				glyphTags = append(glyphTags, gt)
-				glyphTagIndx[tagname] = len(glyphTags) - 1
+	default:
+		fmt.Printf("unknown command [%s]\n", command)
+		os.Exit(3)
+	}
 
-			}
-			indx = glyphTagIndx[tagname]
+	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// read in the blueprint, checking it against the legend; a blueprint that cannot be built as written is reported, line
+	// by line, before any edit is made
+	bp := readBlueprint(*fileBPrnt)
 
-			// digest the elements which make up the definition of this glyphtag
-			for {
-				// we are done when we've run out of elements
-				if match = regexpMatch(eleminfo, `^\s*$`); match { break }
+	// where, and which way around, to build it; turned a quarter, it reaches as far south as it did east, and vice versa
+	at, err := newPlacement(*flagRotate, *flagMirror, 0, 0)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(3)
+	}
+	at.width, at.depth, _ = bp.Extent()
+	width, depth := at.footprint()
+
+	// coordinates for where to start building, so that the blueprint's origin lands on the anchor
+	at.x, at.y, at.z = anchorAtOrigin(*flagOrigin, bp, at, anchorX.Valu, anchorY.Valu, anchorZ.Valu)
+	if *flagOrigin != "nw" {
+		fmt.Printf("build starts at : %d, %d, %d\n", at.x, at.y, at.z)
+		fmt.Printf("\n")
+	}
 
-				_, matches = regexpParse(eleminfo, `^ +([-A-Za-z]{1,4}):([-_a-z0-9]+)`)
-				elemname = matches[1]
-				elemdata = matches[2]
+	// anchored at the player, the blueprint goes ahead of where they stood, now that we know how far it reaches
+	if anchoredAtPlayer {
+		at.x, at.y, at.z = anchorAtPlayer(anchorPlayer, *flagOffset, width, depth)
+		fmt.Printf("build starts at : %d, %d, %d\n", at.x, at.y, at.z)
+		fmt.Printf("\n")
+	}
 
-				// ----:-- is a placeholder, mainly used for empty slots in an item inventory list
-				if elemname == `----` && elemdata == `--` {
-					glyphTags[indx].Indx++
-				}
+	// load all of the regions the blueprint covers at once, rather than one by one as the render reaches them
+	if width > 0 && depth > 0 {
+		gameworld.PreloadRegions(at.x, at.z, at.x + width - 1, at.z + depth - 1)
+	}
 
-				if glyphs[glyphIndx[elemname]].Type == "item" {
-					var nbtI nbt.NBT
-					var nbtG nbt.NBT
+	// == defines a glyph-tag; glyph-tags belong to the blueprint as a whole, and are shared with every blueprint it
+	// includes, so they are all defined before any glyph uses one
+	for _, elem := range bp.Blueprints() {
+		for _, def := range elem.Tags {
+			defineGlyphTag(def.Name, def.Elems)
+		}
+	}
 
+	renderBlueprint(bp, at, 0, 0, 0)
 
-					// if the glyph refers to an item with pre-defined NBT, use that and set its slot
-					// to be the current spot in the inventory list; otherwise construct the item NBT
-					// from the glyph definition
-					//
-					if glyphs[glyphIndx[elemname]].Base != (nbt.NBT{}) {
-						nbtP, _ := glyphs[glyphIndx[elemname]].Base.DeepCopy()
+	finishEdits(*flagDryRun)
+}
 
-						nbtI = *nbtP
-						nbtI.Data.([]nbt.NBT)[1].Data = byte(glyphTags[indx].Indx)
-					} else {
-						item := glyphs[glyphIndx[elemname]]
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+// data handling functions
+//
+
+// renderBlueprint builds a blueprint into the world, and then each of the blueprints it includes;  ox, oy, oz is where the
+// blueprint is within the one being rendered, as written, which the placement then turns into where it is in the world;
+// all of the glyph-tags are already defined
+//
+func renderBlueprint(bp *blueprint.Blueprint, at placement, ox int, oy int, oz int) {
+	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// workhorse variables
+	var biome string
+
+	var dx, dy, dz int
+	var bx, by, bz int
+
+	// %% gives a directive for the blueprint as a whole :
+	//     %% biome : desert  -- sets the biome under the blueprint's footprint (by name, or by number)
+	for _, directive := range bp.Directives {
+		switch directive.Name {
+		case "biome":
+			biome = directive.Value
+		}
+	}
+
+	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// render the blueprint : layers from the bottom up, rows from north to south, and glyphs from west to east
+	for _, layer := range bp.Layers {
+		for _, row := range layer.Rows {
+			// the glyph-tags listed after '::' go to the glyphs on the row that take one, in order
+			gi := 0
+			for _, cell := range row.Cells {
+				// track which world (block) coordinate we are dealing with, as we move
+				// from symbol to symbol on the blueprint
+				bx, by, bz = at.block(ox + dx, oy + dy, oz + dz)
+
+				dx++
+
+				// a gap between the copies of a repeat block leaves the world as it is
+				if cell == nil { continue }
+
+				indx := glyphIndx[cell.Glyph]
+
+				var databyte byte
+				var nbtentity *nbt.NBT
+
+				// glyphs that represent blocks
+				if glyphs[indx].Type == "block" {
 
-						slot := glyphTags[indx].Indx
-						idstr := "minecraft:" + item.Name
-						lenstr := uint32(len(idstr))
+					// this leaves whatever block is already at this spot in the Minecraft world intact
+					if glyphs[indx].Name == "null" || glyphs[indx].Name == "marker" { continue }
 
-						qty, _ := strconv.Atoi(elemdata)
+					databyte = glyphs[indx].Data
 
-						nbtA := nbt.NBT{nbt.TAG_String, 0, "id", lenstr, idstr}
-						nbtB := nbt.NBT{nbt.TAG_Byte, 0, "Slot", 0, byte(slot)}
-						nbtC := nbt.NBT{nbt.TAG_Byte, 0, "Count", 0, byte(qty)}
-						nbtD := nbt.NBT{nbt.TAG_Short, 0, "Damage", 0, int16(item.Data)}
+					// if the glyph refers to a block with pre-defined NBT, use that to also add a
+					// BlockEntity to go with this Block
+					//
+					if glyphs[indx].Base != (nbt.NBT{}) {
+						nbtentity, _ = glyphs[indx].Base.DeepCopy()
+
+						// if the block's NBT has an inventory list, look for a glyphtag and use the
+						// NBT from that glyphtag to fill out this block's blockentity's inventory
+						//
+						if len(nbtentity.Data.([]nbt.NBT)) > 4 {
+							if nbtentity.Data.([]nbt.NBT)[4].Name == "Items" {
+								if gi < len(row.Tags) {
+									ref := row.Tags[gi]
+
+									// if the glyphtag also has a number suffix, use that number
+									// to set the block's data; e.g., the direction a chest faces
+									//
+									if ref.HasData {
+										databyte = byte(ref.Data)
+									}
+
+									nbtentity.Data.([]nbt.NBT)[4] = glyphTags[glyphTagIndx[ref.Name]].Data
+									gi++
+								}
+							}
+						}
 
-						nbtI = nbt.NBT{nbt.TAG_Compound, 0, "LISTELEM", 4, []nbt.NBT{nbtA, nbtB, nbtC, nbtD}}
+						exitOnEditErr(gameworld.EditBlockEntity(bx, by, bz, nbtentity), bx, by, bz)
 					}
 
-					// add the item to the glyphtag definition
-					nbtG = glyphTags[indx].Data
-					tmps := nbtG.Data.([]nbt.NBT)
-					tmps = append(tmps, nbtI)
-					nbtG.Data = tmps
-					nbtG.Size++
+					// a block that faces some way is turned along with the blueprint
+					databyte = at.orientData(glyphs[indx].Orient, databyte)
 
-					glyphTags[indx].Data = nbtG
+					exitOnEditErr(gameworld.EditBlock(bx, by, bz, glyphs[indx].ID, databyte), bx, by, bz)
 
-					glyphTags[indx].Indx++
+					continue
 				}
 
-				if glyphs[glyphIndx[elemname]].Type == "entity" {
+				// glyphs that represent entities
+				if glyphs[indx].Type == "entity" {
 
-					nbtentity := buildEntity(elemdata)
+					// if the glyph is 'E' or 'I', there must be a corrsponding glyphtag; the expectation
+					// is that this glyphtag refers to an entity (built from atoms), and we want to use
+					// that for the NBT for this entity; otherwise, the glyph bears a name that can be used
+					// to build an entity from atoms
+					//
+					if glyphs[indx].Glyph == "E" || glyphs[indx].Glyph == "I" {
+						if gi >= len(row.Tags) {
+							fmt.Printf("%s: more glyphs requiring glyph-tags than glyph-tags listed\n", row.Pos)
+							os.Exit(7)
+						}
 
-					glyphTags = append(glyphTags, GlyphTag{tagname, 0, *nbtentity})
-					glyphTagIndx[tagname] = len(glyphTags) - 1
-				}
+						nbtentity, _ = glyphTags[glyphTagIndx[row.Tags[gi].Name]].Data.DeepCopy()
+						assignEntityUUID(nbtentity)
 
-				// consume the element we just processed
-				_, eleminfo = regexpReplace(eleminfo, `^ +[-A-Za-z]{1,4}:[-_a-z0-9]+`, ``)
-			}
+						gi++
+					} else {
+						nbtentity = buildEntity(glyphs[indx].Name)
+					}
 
-			continue
-		}
+					exitOnEditErr(gameworld.EditEntity(bx, by, bz, nbtentity), bx, by, bz)
+
+					// also make this block an air block, otherwise, if the chunkdata already had a block
+					// in this spot, it will remain; worse, it will potentially suffocate the new entity
+					exitOnEditErr(gameworld.EditBlock(bx, by, bz, 0, 0), bx, by, bz)
 
-		// :: sets a glyph-tag for a glyph within the corresponding glyph line
-		if match, matches = regexpParse(linein, ` *:: +(.+)$`); match {
-			lineglyphtags = strings.Fields(matches[1])
-			_, linein = regexpReplace(linein, ` *:: +.+$`, ``)
+					continue
+				}
+			}
+			dx = 0
+			dz++
 		}
 
-		// glyph line : split the input line into its individual blueprint symbols
-		gg := strings.Fields(linein)
-		gi := 0
-		for _, g := range gg {
-			// track which world (block) coordinate we are dealing with, as we move
-			// from symbol to symbol on the blueprint
-			bx = ax + dx
-			by = ay + dy
-			bz = az + dz
+		// the end of a layer; on to the next one up, back at the north edge
+		dy++
+		dz = 0
+	}
 
-			mx = bx
-			my = by
-			mz = bz
+	// %% include : another blueprint, built after this one's own layers, and so over whatever they put in the same places
+	for _, inc := range bp.Includes {
+		renderBlueprint(inc.Blueprint, at, ox + inc.X, oy + inc.Y, oz + inc.Z)
+	}
 
-			dx++
+	// biomes set by a blueprint go under its whole footprint, including whatever it includes, wherever its opposite
+	// corners are turned to
+	width, depth, _ := bp.Extent()
+	if biome != "" && width > 0 && depth > 0 {
+		x1, _, z1 := at.block(ox, 0, oz)
+		x2, _, z2 := at.block(ox + width - 1, 0, oz + depth - 1)
+		if x1 > x2 { x1, x2 = x2, x1 }
+		if z1 > z2 { z1, z2 = z2, z1 }
+		paintBiome(biome, x1, z1, x2, z2)
+	}
+}
 
-			indx := glyphIndx[g]
+// finishEdits ends a run that edits the world : for a dry run, it reports the plan; otherwise, it saves the edits and
+// reports what was done
+//
+func finishEdits(dryRun bool) {
+	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// for a dry run, report the plan instead of saving anything
+	if dryRun {
+		fmt.Printf("\n")
+		gameworld.Plan.Report(os.Stdout, describeBlock)
+		fmt.Printf("dry run; no region files were changed\n")
+		fmt.Printf("\n")
+		os.Exit(0)
+	}
 
-			var databyte byte
-			var nbtentity *nbt.NBT
+	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+	// save the net effect of all edits to new region file(s)
+	err := gameworld.SaveAllEdits()
+	if err != nil {
+		fmt.Printf("unable to save the world [%s] [%s]\n", gameworld.PathWorld, err)
+		os.Exit(3)
+	}
+	fmt.Printf("\n")
 
-			// glyphs that represent blocks
-			if glyphs[indx].Type == "block" {
+	// output stats on what was done
+	fmt.Printf("block edits                : %d\n", gameworld.Stats.BlockEdits)
+	fmt.Printf("block edits skipped        : %d\n", gameworld.Stats.BlockEditsSkipped)
+	fmt.Printf("entity edits               : %d\n", gameworld.Stats.EntityEdits)
+	fmt.Printf("entity edits skipped       : %d\n", gameworld.Stats.EntityEditsSkipped)
+	fmt.Printf("blockentity edits          : %d\n", gameworld.Stats.BlockEntityEdits)
+	fmt.Printf("blockentity edits skipped  : %d\n", gameworld.Stats.BlockEntityEditsSkipped)
+	fmt.Printf("chunks decoded             : %d\n", gameworld.Stats.ChunksDecoded)
+	fmt.Printf("chunks copied unchanged    : %d\n", gameworld.Stats.ChunksPassedThrough)
+	fmt.Printf("biome edits                : %d\n", gameworld.Stats.BiomeEdits)
+	fmt.Printf("biome edits skipped        : %d\n", gameworld.Stats.BiomeEditsSkipped)
+	fmt.Printf("chunks relit               : %d\n", gameworld.Stats.ChunksRelit)
+	fmt.Printf("\n")
 
-				// this leaves whatever block is already at this spot in the Minecraft world intact
-				if glyphs[indx].Name == "null" { continue }
+	os.Exit(0)
+}
 
-				databyte = glyphs[indx].Data
+// defineGlyphTag adds the elements listed on a '==' line to the glyph-tag they are listed for; a glyph-tag built up from
+// items is an inventory list, and one built from an entity is that entity;  items are described as they were before 1.13,
+// and are converted as they are added for a world of 1.13 or later, as its level.dat says (see world.FlattenItem)
+//
+func defineGlyphTag(tagname string, elems []*blueprint.TagElem) {
+	var elemname string
+	var elemdata string
+	var indx int
 
-				// if the glyph refers to a block with pre-defined NBT, use that to also add a
-				// BlockEntity to go with this Block
-				//
-				if glyphs[indx].Base != (nbt.NBT{}) {
-					nbtentity, _ = glyphs[indx].Base.DeepCopy()
+	// if we have not seen this glyphtag before, define it as a glyphtag and in the glyphtag map
+	if _, okay := glyphTagIndx[tagname]; !okay {
+		gt := GlyphTag{tagname, 0, nbt.NBT{nbt.TAG_List, nbt.TAG_Compound, "Items", 0, make([]nbt.NBT, 0)}}
+		glyphTags = append(glyphTags, gt)
+		glyphTagIndx[tagname] = len(glyphTags) - 1
 
-					// if the block's NBT has an inventory list, look for a glyphtag and use the
-					// NBT from that glyphtag to fill out this block's blockentity's inventory
-					//
-					if len(nbtentity.Data.([]nbt.NBT)) > 4 {
-						if nbtentity.Data.([]nbt.NBT)[4].Name == "Items" {
-							if gi < len(lineglyphtags) {
-								lineglyphtag := lineglyphtags[gi]
-
-								// if the glyphtag also has a number suffix, use that number
-								// to set the block's data; e.g., the direction a chest faces
-								//
-								if match, matches = regexpParse(lineglyphtag, `^([a-z]+):([0-9]+)$`); match {
-									lineglyphtag = matches[1]
-									i, _ := strconv.ParseUint(matches[2], 10, 8)
-									databyte = byte(i)
-								}
+	}
+	indx = glyphTagIndx[tagname]
 
-								nbtentity.Data.([]nbt.NBT)[4] = glyphTags[glyphTagIndx[lineglyphtag]].Data
-								gi++
-							}
-						}
-					}
+	// digest the elements which make up the definition of this glyphtag
+	for _, elem := range elems {
+		elemname = elem.Glyph
+		elemdata = elem.Data
 
-					world.EditBlockEntity(bx, by, bz, nbtentity)
-				}
+		// ----:-- is a placeholder, mainly used for empty slots in an item inventory list
+		if elemname == `----` && elemdata == `--` {
+			glyphTags[indx].Indx++
+		}
 
-				world.EditBlock(bx, by, bz, glyphs[indx].ID, databyte)
+		if glyphs[glyphIndx[elemname]].Type == "item" {
+			var nbtI nbt.NBT
+			var nbtG nbt.NBT
 
-				continue
-			}
 
-			// glyphs that represent entities
-			if glyphs[indx].Type == "entity" {
+			// if the glyph refers to an item with pre-defined NBT, use that and set its slot
+			// to be the current spot in the inventory list; otherwise construct the item NBT
+			// from the glyph definition
+			//
+			if glyphs[glyphIndx[elemname]].Base != (nbt.NBT{}) {
+				nbtP, _ := glyphs[glyphIndx[elemname]].Base.DeepCopy()
 
-				// if the glyph is 'E' or 'I', there must be a corrsponding glyphtag; the expectation
-				// is that this glyphtag refers to an entity (built from atoms), and we want to use
-				// that for the NBT for this entity; otherwise, the glyph bears a name that can be used
-				// to build an entity from atoms
-				//
-				if glyphs[indx].Glyph == "E" || glyphs[indx].Glyph == "I" {
-					if gi >= len(lineglyphtags) {
-						fmt.Printf("more glyphs requiring glyph-tags than glyph-tags listed [%s]\n", linein)
-						os.Exit(7)
-					}
+				nbtI = *nbtP
+				nbtI.Data.([]nbt.NBT)[1].Data = byte(glyphTags[indx].Indx)
+			} else {
+				item := glyphs[glyphIndx[elemname]]
 
-					nbtentity, _ = glyphTags[glyphTagIndx[lineglyphtags[gi]]].Data.DeepCopy()
-					assignEntityUUID(nbtentity)
+				slot := glyphTags[indx].Indx
+				idstr := "minecraft:" + item.Name
+				lenstr := uint32(len(idstr))
+
+				qty, _ := strconv.Atoi(elemdata)
+
+				nbtA := nbt.NBT{nbt.TAG_String, 0, "id", lenstr, idstr}
+				nbtB := nbt.NBT{nbt.TAG_Byte, 0, "Slot", 0, byte(slot)}
+				nbtC := nbt.NBT{nbt.TAG_Byte, 0, "Count", 0, byte(qty)}
+				nbtD := nbt.NBT{nbt.TAG_Short, 0, "Damage", 0, int16(item.Data)}
+
+				nbtI = nbt.NBT{nbt.TAG_Compound, 0, "LISTELEM", 4, []nbt.NBT{nbtA, nbtB, nbtC, nbtD}}
+			}
 
-					gi++
-				} else {
-					nbtentity = buildEntity(glyphs[indx].Name)
+			// a world of 1.13 or later has no use for an item as it was before, and drops it
+			if gameworld.Level != nil && gameworld.Level.Format.Codec == "palette" {
+				err := world.FlattenItem(&nbtI, gameworld.Level.DataVersion)
+				if err != nil {
+					fmt.Printf("unable to put [%s] in glyph-tag [%s] for Minecraft %s [%s]\n", elemname, tagname, gameworld.Level.Format.Name, err)
+					os.Exit(3)
 				}
+			}
+
+			// add the item to the glyphtag definition
+			nbtG = glyphTags[indx].Data
+			tmps := nbtG.Data.([]nbt.NBT)
+			tmps = append(tmps, nbtI)
+			nbtG.Data = tmps
+			nbtG.Size++
+
+			glyphTags[indx].Data = nbtG
+
+			glyphTags[indx].Indx++
+		}
+
+		if glyphs[glyphIndx[elemname]].Type == "entity" {
+
+			nbtentity := buildEntity(elemdata)
+
+			glyphTags = append(glyphTags, GlyphTag{tagname, 0, *nbtentity})
+			glyphTagIndx[tagname] = len(glyphTags) - 1
+		}
+	}
+}
+
+// readBlueprint parses a blueprint file and lints it against the legend, reporting every problem found; a blueprint with
+// errors is not used at all
+//
+func readBlueprint(filename string) (bp *blueprint.Blueprint) {
+	bp, diags := lintBlueprint(filename)
+
+	for _, elem := range diags {
+		fmt.Printf("%s\n", elem)
+	}
+	if diags.HasErrors() {
+		fmt.Printf("unable to use blueprint file [%s]; see above\n", filename)
+		os.Exit(7)
+	}
 
-				world.EditEntity(bx, by, bz, nbtentity)
+	return
+}
+
+// lintBlueprint finds everything wrong with a blueprint file, and with every blueprint it includes :  what they cannot
+// parse, what the legend makes of them, and any biome they name that there is no such biome for
+//
+func lintBlueprint(filename string) (bp *blueprint.Blueprint, diags blueprint.Diagnostics) {
+	bp, err := blueprint.Load(filename)
+	if err != nil {
+		var okay bool
+		if diags, okay = err.(blueprint.Diagnostics); !okay {
+			fmt.Printf("unable to open blueprint file [%s] [%s]\n", filename, err)
+			os.Exit(3)
+		}
+	}
 
-				// also make this block an air block, otherwise, if the chunkdata already had a block
-				// in this spot, it will remain; worse, it will potentially suffocate the new entity
-				world.EditBlock(bx, by, bz, 0, 0)
+	diags = append(diags, blueprint.Lint(bp, legend{})...)
 
-				continue
+	for _, elem := range bp.Blueprints() {
+		for _, directive := range elem.Directives {
+			if directive.Name == "biome" {
+				if _, err = world.BiomeID(directive.Value); err != nil {
+					diags = append(diags, blueprint.Diagnostic{Pos: directive.Pos, Severity: blueprint.SeverityError, Message: err.Error()})
+				}
 			}
 		}
-		dx = 0
-		dz++
+	}
+	diags.Sort()
+
+	return
+}
 
-		lineglyphtags = nil
+// giveGlyphTag puts the items of a glyph-tag defined in a blueprint file straight into a player's inventory or ender chest,
+// each in the slot it would have in a chest, or, where the player already has something there, in a free slot
+//
+func giveGlyphTag(filename string, tagname string, player string, enderChest bool, dryRun bool) {
+	// only the glyph-tags of the blueprint, and of those it includes, matter here
+	bp := readBlueprint(filename)
+	for _, elem := range bp.Blueprints() {
+		for _, def := range elem.Tags {
+			defineGlyphTag(def.Name, def.Elems)
+		}
 	}
 
-	err = scanner.Err()
-	panicOnErr(err)
+	indx, okay := glyphTagIndx[tagname]
+	if !okay || glyphTags[indx].Data.Name != "Items" {
+		fmt.Printf("no glyph-tag of items [%s] in blueprint file [%s]\n", tagname, filename)
+		os.Exit(3)
+	}
+
+	list := "Inventory"
+	if enderChest {
+		list = "EnderItems"
+	}
+
+	p, err := gameworld.ReadPlayer(player)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(3)
+	}
+
+	given, moved, err := p.GiveItems(list, glyphTags[indx].Data.Data.([]nbt.NBT))
+	if err != nil {
+		fmt.Printf("unable to give [%s] to player [%s] [%s]\n", tagname, p.UUID, err)
+		os.Exit(3)
+	}
 
-	for indxz := az; indxz < mz; indxz++ {
-		for indxx := ax; indxx < mx; indxx++ {
-			world.FixHeightMaps(indxx, my, indxz)
+	if !dryRun {
+		err = gameworld.SavePlayer(p)
+		if err != nil {
+			fmt.Printf("unable to save player [%s] [%s]\n", p.Filename, err)
+			os.Exit(3)
 		}
 	}
 
-	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-	// save the net effect of all edits to new region file(s)
-	world.SaveAllEdits()
 	fmt.Printf("\n")
-
-	// output stats on what was done
-	fmt.Printf("block edits                : %d\n", qtyBlockEdits)
-	fmt.Printf("block edits skipped        : %d\n", qtyBlockEditsSkipped)
-	fmt.Printf("entity edits               : %d\n", qtyEntityEdits)
-	fmt.Printf("entity edits skipped       : %d\n", qtyEntityEditsSkipped)
-	fmt.Printf("blockentity edits          : %d\n", qtyBlockEntityEdits)
-	fmt.Printf("blockentity edits skipped  : %d\n", qtyBlockEntityEditsSkipped)
+	fmt.Printf("player file                : %s\n", p.Filename)
+	fmt.Printf("items given                : %d (to %s)\n", given, list)
+	fmt.Printf("items moved to free slots  : %d\n", moved)
+	if dryRun {
+		fmt.Printf("dry run; the player file was not changed\n")
+	}
 	fmt.Printf("\n")
 
 	os.Exit(0)
 }
 
-///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
-// data handling functions
+// anchorAtOrigin works out where a blueprint starts, for its origin to land on ax, ay, az :  a corner of its footprint, or
+// its centre, as built (i.e., after any -rotate or -mirror), or wherever the one glyph named is, wherever that ends up
+//
+func anchorAtOrigin(origin string, bp *blueprint.Blueprint, at placement, ax int, ay int, az int) (x int, y int, z int) {
+	width, depth := at.footprint()
+	at.x, at.y, at.z = 0, 0, 0
+
+	var ox, oy, oz int
+	switch origin {
+	case "nw":
+		ox, oz = 0, 0
+	case "ne":
+		ox, oz = width - 1, 0
+	case "sw":
+		ox, oz = 0, depth - 1
+	case "se":
+		ox, oz = width - 1, depth - 1
+	case "center", "centre":
+		ox, oz = (width - 1) / 2, (depth - 1) / 2
+
+	default:
+		if !strings.HasPrefix(origin, "glyph:") {
+			fmt.Printf("unknown origin [%s]; expected 'nw', 'ne', 'sw', 'se', 'center' or 'glyph:G'\n", origin)
+			os.Exit(3)
+		}
+
+		// a marker glyph has to be somewhere, and only in one place, to say where the origin is
+		glyph := strings.TrimPrefix(origin, "glyph:")
+		spots := bp.Find(glyph)
+		if len(spots) != 1 {
+			fmt.Printf("unable to use glyph [%s] as the origin; it appears %d times in the blueprint, rather than once\n", glyph, len(spots))
+			for _, elem := range spots {
+				fmt.Printf("%s: glyph [%s]\n", elem.Pos, glyph)
+			}
+			os.Exit(3)
+		}
+		ox, oy, oz = at.block(spots[0].X, spots[0].Y, spots[0].Z)
+	}
+
+	return ax - ox, ay - oy, az - oz
+}
+
+// anchorAtPlayer works out where a blueprint starts, to be built ahead of where the player stood, and to their right, from
+// the block the offset (ahead, up, right) leads to;  the blueprint itself is not turned to face the way the player did (see
+// -rotate for that), so its footprint, as built, runs in whichever directions those are, and its west, north corner is
+// wherever that leaves it
+//
+func anchorAtPlayer(player string, offset string, width int, depth int) (x int, y int, z int) {
+	var ahead, up, right int
+	_, err := fmt.Sscanf(offset, "%d,%d,%d", &ahead, &up, &right)
+	if err != nil {
+		fmt.Printf("unable to read -offset [%s]; expected ahead,up,right (e.g., '1,0,0')\n", offset)
+		os.Exit(3)
+	}
+
+	p, err := gameworld.ReadPlayer(player)
+	if err == nil && p.Dimension() != gameworld.Dimension {
+		err = fmt.Errorf("the player is in the %s, not the %s; choose the player's dimension with -dimension", p.Dimension(), gameworld.Dimension)
+	}
+	if err != nil {
+		fmt.Printf("unable to anchor at the player [%s]\n", err)
+		os.Exit(3)
+	}
+
+	px, py, pz, yaw, err := p.Position()
+	if err != nil {
+		fmt.Printf("unable to anchor at the player [%s]\n", err)
+		os.Exit(3)
+	}
+
+	// the way the player faced, to the nearest of south, west, north and east, and which way is then to their right
+	facing := int(math.Floor(math.Mod(math.Mod(float64(yaw), 360) + 360 + 45, 360) / 90))
+	aheadX := []int{0, -1, 0, 1}[facing]
+	aheadZ := []int{1, 0, -1, 0}[facing]
+	rightX := -aheadZ
+	rightZ := aheadX
+	who := p.UUID
+	if who == "" {
+		who = "the single-player world's player"
+	}
+	fmt.Printf("player          : %s at %.1f, %.1f, %.1f, facing %s\n", who, px, py, pz, []string{"south", "west", "north", "east"}[facing])
+
+	// the blueprint runs from this corner of its footprint, the one nearest the player, on their left ...
+	x = int(math.Floor(px)) + (aheadX * ahead) + (rightX * right)
+	y = int(math.Floor(py)) + up
+	z = int(math.Floor(pz)) + (aheadZ * ahead) + (rightZ * right)
+
+	// ... to the opposite corner, ahead and to the right, by the extent the blueprint has along each of those
+	lenAhead, lenRight := depth, width
+	if aheadX != 0 {
+		lenAhead, lenRight = width, depth
+	}
+	farX := x + (aheadX * (lenAhead - 1)) + (rightX * (lenRight - 1))
+	farZ := z + (aheadZ * (lenAhead - 1)) + (rightZ * (lenRight - 1))
+
+	if farX < x { x = farX }
+	if farZ < z { z = farZ }
+
+	return
+}
+
+// paintBiome sets the biome of every column from x1, z1 to x2, z2, inclusive
 //
+func paintBiome(biome string, x1 int, z1 int, x2 int, z2 int) {
+	id, err := world.BiomeID(biome)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(3)
+	}
+
+	if x1 > x2 {
+		x1, x2 = x2, x1
+	}
+	if z1 > z2 {
+		z1, z2 = z2, z1
+	}
+
+	for z := z1; z <= z2; z++ {
+		for x := x1; x <= x2; x++ {
+			err = gameworld.EditBiome(x, z, id)
+			if err != nil {
+				fmt.Printf("unable to set the biome at %d, %d [%s]\n", x, z, err)
+				os.Exit(3)
+			}
+		}
+	}
+}
+
 func buildEntity(top string) (rslt *nbt.NBT) {
 	var stack []string
 	var next string
@@ -541,7 +1060,8 @@ func assignEntityUUID(dst *nbt.NBT) {
 	uuidmost := int64(binary.BigEndian.Uint64(uuid[0:8]))
 	uuidlest := int64(binary.BigEndian.Uint64(uuid[8:16]))
 
-	// modify the entity to have its own UUID;  we know the array indexes, because we constructed the entity
+	// modify the entity to have its own UUID;  we know the array indexes, because we constructed the entity;  for a chunk
+	// of 1.16 or later, EditEntity rewrites it into the form that version keeps it in
 	dst.Data.([]nbt.NBT)[1].Data = uuidmost
 	dst.Data.([]nbt.NBT)[2].Data = uuidlest
 }
@@ -549,53 +1069,19 @@ func assignEntityUUID(dst *nbt.NBT) {
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // utility functions
 
-func panicOnErr(e error) {
-	if e != nil {
-		panic(e)
-	}
-}
-
-// these regexp functions are intended to make the most common regular-expression use cases easier to write, read, and maintain
+// the world reports what goes wrong with an edit, rather than stopping us; but a render half-done is not worth saving, so
+// we stop there ourselves, before anything is written;  regions with edits are held in memory until the end of the run
+// (see FlagHoldEdits), rather than saved as they make room in the region cache, so that this is so
 //
-func regexpMatch(subject string, pattern string) (rtrn bool) {
-	rtrn = false
-
-	var re *regexp.Regexp
-
-	re = regexp.MustCompile(pattern)
-	if re.FindStringIndex(subject) != nil {
-		rtrn = true
-	}
-
-	return
-}
-
-func regexpReplace(subject string, pattern string, replace string) (rtrn bool, rslt string) {
-	rtrn = false
-	rslt = subject
-
-	var re *regexp.Regexp
-
-	re = regexp.MustCompile(pattern)
-	if re.FindStringIndex(subject) != nil {
-		rtrn = true
-		rslt = re.ReplaceAllLiteralString(subject, replace)
+func exitOnEditErr(e error, x int, y int, z int) {
+	if e != nil {
+		fmt.Printf("unable to edit the world at %d, %d, %d [%s]\n", x, y, z, e)
+		os.Exit(3)
 	}
-
-	return
 }
 
-func regexpParse(subject string, pattern string) (rtrn bool, rslt []string) {
-	rtrn = false
-	rslt = nil
-
-	var re *regexp.Regexp
-
-	re = regexp.MustCompile(pattern)
-	if re.FindStringIndex(subject) != nil {
-		rtrn = true
-		rslt = re.FindStringSubmatch(subject)
+func panicOnErr(e error) {
+	if e != nil {
+		panic(e)
 	}
-
-	return
 }